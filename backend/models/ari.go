@@ -0,0 +1,287 @@
+package models
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ARI formats a channel can take
+const (
+	ARIFormatJSON = "json"
+	ARIFormatOTA  = "ota-xml"
+)
+
+// ARIHorizonDays is how many nights ahead availability and rates are pushed
+const ARIHorizonDays = 90
+
+// ARIRateGuests are the occupancies rates are published for
+var ARIRateGuests = []int{1, 2}
+
+// ErrARIPushFailed is returned when a channel does not accept an ARI push
+var ErrARIPushFailed = errors.New("ARI push failed")
+
+const otaNamespace = "http://www.opentravel.org/OTA/2003/05"
+
+// ARIRate is the nightly rate for a number of guests
+type ARIRate struct {
+	Guests int     `json:"guests"`
+	Amount float64 `json:"amount"`
+}
+
+// ARIDay is the availability, restrictions and rates of one room type
+// under one rate plan for one night
+type ARIDay struct {
+	Date      time.Time `json:"date"`
+	RoomType  string    `json:"room_type"`
+	RatePlan  string    `json:"rate_plan"`
+	Available int       `json:"available"` // sellable rooms, including the overbooking allowance
+	Closed    bool      `json:"closed"`    // stop sell
+	MinNights int       `json:"min_nights"`
+	Rates     []ARIRate `json:"rates"`
+}
+
+// ARIExport is the availability, rates and inventory published to a channel
+type ARIExport struct {
+	Channel     string    `json:"channel"`
+	HotelCode   string    `json:"hotel_code"`
+	Currency    string    `json:"currency"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Days        []ARIDay  `json:"days"`
+}
+
+// BuildARI prices every room type under each of the channel's rate plans
+// (BAR if none) for every night from from up to, but not including, to
+func BuildARI(tx *gorm.DB, channel *SalesChannel, from, to, now time.Time) (*ARIExport, error) {
+	from, to = atHour(from, 0), atHour(to, 0)
+	days, err := InventoryCalendar(tx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	baseRates, err := roomTypeBaseRates(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	plans := []string{RatePlanBAR}
+	if len(channel.RatePlans) > 0 {
+		plans = plans[:0]
+		for _, plan := range channel.RatePlans {
+			plans = append(plans, fmt.Sprint(plan))
+		}
+	}
+
+	export := &ARIExport{
+		Channel:     channel.Code,
+		HotelCode:   channel.HotelCode,
+		Currency:    channel.Currency,
+		From:        from,
+		To:          to,
+		GeneratedAt: now,
+	}
+	for _, code := range plans {
+		pricer, err := newRatePricer(tx, code, from, to, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, day := range days {
+			ari := ARIDay{
+				Date:      day.Date,
+				RoomType:  day.RoomType,
+				RatePlan:  pricer.plan.Code,
+				Available: day.Sellable,
+				Closed:    day.Sellable == 0,
+				MinNights: pricer.plan.MinNights,
+			}
+			for _, guests := range ARIRateGuests {
+				rate := pricer.night(day.RoomType, baseRates[day.RoomType], day.Date, guests)
+				ari.Rates = append(ari.Rates, ARIRate{Guests: guests, Amount: rate.Rate})
+			}
+			export.Days = append(export.Days, ari)
+		}
+	}
+	return export, nil
+}
+
+type otaStatusControl struct {
+	Start        string `xml:"Start,attr"`
+	End          string `xml:"End,attr"`
+	InvTypeCode  string `xml:"InvTypeCode,attr"`
+	RatePlanCode string `xml:"RatePlanCode,attr"`
+}
+
+type otaLengthOfStay struct {
+	Time              int    `xml:"Time,attr"`
+	TimeUnit          string `xml:"TimeUnit,attr"`
+	MinMaxMessageType string `xml:"MinMaxMessageType,attr"`
+}
+
+type otaLengthsOfStay struct {
+	Items []otaLengthOfStay `xml:"LengthOfStay"`
+}
+
+type otaAvailStatusMessage struct {
+	BookingLimit  int               `xml:"BookingLimit,attr"`
+	Control       otaStatusControl  `xml:"StatusApplicationControl"`
+	LengthsOfStay *otaLengthsOfStay `xml:"LengthsOfStay,omitempty"`
+	Restriction   struct {
+		Status string `xml:"Status,attr"`
+	} `xml:"RestrictionStatus"`
+}
+
+type otaHotelAvailNotifRQ struct {
+	XMLName   xml.Name `xml:"OTA_HotelAvailNotifRQ"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	Version   string   `xml:"Version,attr"`
+	TimeStamp string   `xml:"TimeStamp,attr"`
+	Messages  struct {
+		HotelCode string                  `xml:"HotelCode,attr"`
+		Items     []otaAvailStatusMessage `xml:"AvailStatusMessage"`
+	} `xml:"AvailStatusMessages"`
+}
+
+type otaBaseByGuestAmt struct {
+	NumberOfGuests int    `xml:"NumberOfGuests,attr"`
+	AmountAfterTax string `xml:"AmountAfterTax,attr"`
+}
+
+type otaRateAmountMessage struct {
+	Control otaStatusControl `xml:"StatusApplicationControl"`
+	Rate    struct {
+		CurrencyCode string              `xml:"CurrencyCode,attr"`
+		Amounts      []otaBaseByGuestAmt `xml:"BaseByGuestAmts>BaseByGuestAmt"`
+	} `xml:"Rates>Rate"`
+}
+
+type otaHotelRateAmountNotifRQ struct {
+	XMLName   xml.Name `xml:"OTA_HotelRateAmountNotifRQ"`
+	Xmlns     string   `xml:"xmlns,attr"`
+	Version   string   `xml:"Version,attr"`
+	TimeStamp string   `xml:"TimeStamp,attr"`
+	Messages  struct {
+		HotelCode string                 `xml:"HotelCode,attr"`
+		Items     []otaRateAmountMessage `xml:"RateAmountMessage"`
+	} `xml:"RateAmountMessages"`
+}
+
+// OTAMessages renders the export as OpenTravel OTA_HotelAvailNotifRQ and
+// OTA_HotelRateAmountNotifRQ documents, one message per night
+func (e *ARIExport) OTAMessages() (avail, rates []byte, err error) {
+	timestamp := e.GeneratedAt.Format(time.RFC3339)
+	availRQ := otaHotelAvailNotifRQ{Xmlns: otaNamespace, Version: "1.0", TimeStamp: timestamp}
+	availRQ.Messages.HotelCode = e.HotelCode
+	ratesRQ := otaHotelRateAmountNotifRQ{Xmlns: otaNamespace, Version: "1.0", TimeStamp: timestamp}
+	ratesRQ.Messages.HotelCode = e.HotelCode
+	for _, day := range e.Days {
+		control := otaStatusControl{
+			Start:        day.Date.Format(dateKey),
+			End:          day.Date.Format(dateKey),
+			InvTypeCode:  day.RoomType,
+			RatePlanCode: day.RatePlan,
+		}
+
+		status := otaAvailStatusMessage{BookingLimit: day.Available, Control: control}
+		status.Restriction.Status = "Open"
+		if day.Closed {
+			status.Restriction.Status = "Close"
+		}
+		if day.MinNights > 0 {
+			status.LengthsOfStay = &otaLengthsOfStay{Items: []otaLengthOfStay{
+				{Time: day.MinNights, TimeUnit: "Day", MinMaxMessageType: "SetMinLOS"},
+			}}
+		}
+		availRQ.Messages.Items = append(availRQ.Messages.Items, status)
+
+		amount := otaRateAmountMessage{Control: control}
+		amount.Rate.CurrencyCode = e.Currency
+		for _, rate := range day.Rates {
+			amount.Rate.Amounts = append(amount.Rate.Amounts, otaBaseByGuestAmt{
+				NumberOfGuests: rate.Guests,
+				AmountAfterTax: fmt.Sprintf("%.2f", rate.Amount),
+			})
+		}
+		ratesRQ.Messages.Items = append(ratesRQ.Messages.Items, amount)
+	}
+
+	if avail, err = xml.MarshalIndent(availRQ, "", "  "); err != nil {
+		return nil, nil, err
+	}
+	if rates, err = xml.MarshalIndent(ratesRQ, "", "  "); err != nil {
+		return nil, nil, err
+	}
+	return append([]byte(xml.Header), avail...), append([]byte(xml.Header), rates...), nil
+}
+
+// PushARI sends the next ARIHorizonDays nights of ARI to the channel's
+// endpoint in its format and records when it was pushed
+func PushARI(ctx context.Context, client *http.Client, tx *gorm.DB, channel *SalesChannel, now time.Time) error {
+	from := atHour(now, 0)
+	export, err := BuildARI(tx, channel, from, from.AddDate(0, 0, ARIHorizonDays), now)
+	if err != nil {
+		return err
+	}
+
+	var bodies [][]byte
+	contentType := "application/json"
+	if channel.ARIFormat == ARIFormatOTA {
+		avail, rates, err := export.OTAMessages()
+		if err != nil {
+			return err
+		}
+		bodies, contentType = [][]byte{avail, rates}, "application/xml"
+	} else {
+		body, err := json.Marshal(export)
+		if err != nil {
+			return err
+		}
+		bodies = [][]byte{body}
+	}
+
+	for _, body := range bodies {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, channel.ARIEndpoint, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", contentType)
+		resp, err := client.Do(req)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrARIPushFailed, channel.Code, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("%w: %s returned %s", ErrARIPushFailed, channel.Code, resp.Status)
+		}
+	}
+
+	channel.LastARIPushAt = &now
+	return tx.Model(channel).Update("last_ari_push_at", now).Error
+}
+
+// PushAllARI pushes ARI to every active channel with an endpoint. A failing
+// channel does not stop the others. It is run every 15 minutes.
+func PushAllARI(ctx context.Context, client *http.Client, tx *gorm.DB, now time.Time) error {
+	var channels []SalesChannel
+	if err := tx.Where("active = ? AND ari_endpoint <> ?", true, "").Find(&channels).Error; err != nil {
+		return err
+	}
+	var failed []string
+	for i := range channels {
+		if err := PushARI(ctx, client, tx, &channels[i], now); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, "; "))
+	}
+	return nil
+}
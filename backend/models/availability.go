@@ -0,0 +1,64 @@
+package models
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ActiveReservationStatuses are the statuses that hold a room for their dates
+var ActiveReservationStatuses = []string{
+	ReservationStatusPending,
+	ReservationStatusConfirmed,
+	ReservationStatusCheckedIn,
+}
+
+// RoomIsFree reports whether no active reservation other than
+// excludeReservationID overlaps the given stay in the room. Overbooked
+// reservations only hold a placeholder room and do not count.
+func RoomIsFree(tx *gorm.DB, roomID uint, checkIn, checkOut time.Time, excludeReservationID uint) (bool, error) {
+	var overlapping int64
+	err := tx.Model(&Reservation{}).
+		Where("room_id = ? AND id <> ? AND overbooked = ? AND status IN ? AND check_in_date < ? AND check_out_date > ?",
+			roomID, excludeReservationID, false, ActiveReservationStatuses, checkOut, checkIn).
+		Count(&overlapping).Error
+	return overlapping == 0, err
+}
+
+// FindEquivalentRoom finds a free room of the same type and at least the same
+// capacity for the stay, preferring the same floor and then the closest
+// price. Rooms in skip are not considered. It returns nil if none is free.
+func FindEquivalentRoom(tx *gorm.DB, room Room, checkIn, checkOut time.Time, excludeReservationID uint, skip map[uint]bool) (*Room, error) {
+	var candidates []Room
+	err := tx.Where("id <> ? AND room_type = ? AND capacity >= ? AND status <> ?",
+		room.ID, room.RoomType, room.Capacity, RoomStatusMaintenance).
+		Find(&candidates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		sameI, sameJ := candidates[i].Floor == room.Floor, candidates[j].Floor == room.Floor
+		if sameI != sameJ {
+			return sameI
+		}
+		return math.Abs(candidates[i].PricePerNight-room.PricePerNight) <
+			math.Abs(candidates[j].PricePerNight-room.PricePerNight)
+	})
+
+	for i := range candidates {
+		if skip[candidates[i].ID] {
+			continue
+		}
+		free, err := RoomIsFree(tx, candidates[i].ID, checkIn, checkOut, excludeReservationID)
+		if err != nil {
+			return nil, err
+		}
+		if free {
+			return &candidates[i], nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,339 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PublicHoldMinutes is how long a website booking holds its room while the
+// guest pays the deposit
+const PublicHoldMinutes = 20
+
+// PublicDepositPercent is the share of the total due to confirm a website
+// booking. Non-refundable rates are paid in full.
+const PublicDepositPercent = 20
+
+// PublicMaxNights is the longest stay that can be booked on the website
+const PublicMaxNights = 30
+
+// PublicRatePlans are the rate plans sold on the website
+var PublicRatePlans = []string{RatePlanBAR, RatePlanNonRefundable, RatePlanBreakfast}
+
+// Rate limits of the public booking API, per client IP
+var (
+	PublicSearchLimiter  = NewRateLimiter(30, 10)
+	PublicBookingLimiter = NewRateLimiter(5, 3)
+)
+
+// Booking engine errors
+var (
+	ErrRatePlanNotPublic = errors.New("rate plan is not sold on the website")
+	ErrBookingNotFound   = errors.New("booking not found")
+	ErrHoldExpired       = errors.New("booking hold has expired")
+	ErrDepositTooLow     = errors.New("deposit is less than the amount due")
+	ErrRoomTypeRequired  = errors.New("room type is required")
+	ErrGuestNameRequired = errors.New("guest name and email are required")
+)
+
+// PublicStay is a stay searched for, quoted or booked on the website
+type PublicStay struct {
+	CheckInDate   time.Time
+	CheckOutDate  time.Time
+	Guests        int
+	RoomType      string // not used by search
+	RatePlan      string // BAR if empty; not used by search
+	PromotionCode string
+}
+
+// RoomTypeOffer is a room type free for a searched stay, priced under each
+// website rate plan it can be sold on
+type RoomTypeOffer struct {
+	RoomType  string
+	MaxGuests int
+	Available int // rooms left for the stay, without the overbooking allowance
+	Quotes    []StayQuote
+}
+
+// PublicGuest is the guest making a website booking
+type PublicGuest struct {
+	Name        string
+	Email       string
+	Phone       string
+	Nationality string
+}
+
+// SearchAvailability lists the room types with a room sleeping the party
+// free for the stay, cheapest first, each priced under every website rate
+// plan the stay qualifies for
+func SearchAvailability(tx *gorm.DB, stay PublicStay, now time.Time) ([]RoomTypeOffer, error) {
+	if err := checkPublicStay(&stay, now); err != nil {
+		return nil, err
+	}
+	days, err := InventoryCalendar(tx, stay.CheckInDate, stay.CheckOutDate)
+	if err != nil {
+		return nil, err
+	}
+	available := map[string]int{}
+	var roomTypes []string
+	for _, day := range days {
+		left, seen := available[day.RoomType]
+		if !seen {
+			roomTypes = append(roomTypes, day.RoomType)
+		}
+		if sellable := publicSellable(day); !seen || sellable < left {
+			available[day.RoomType] = sellable
+		}
+	}
+
+	var offers []RoomTypeOffer
+	for _, roomType := range roomTypes {
+		if available[roomType] <= 0 {
+			continue
+		}
+		room, err := findFreeRoomOfType(tx, roomType, stay.Guests, stay.CheckInDate, stay.CheckOutDate, 0)
+		if errors.Is(err, ErrNoRoomAvailable) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var maxGuests int
+		err = tx.Model(&Room{}).Select("COALESCE(MAX(capacity), 0)").
+			Where("room_type = ? AND status <> ?", roomType, RoomStatusMaintenance).Scan(&maxGuests).Error
+		if err != nil {
+			return nil, err
+		}
+
+		offer := RoomTypeOffer{RoomType: roomType, MaxGuests: maxGuests, Available: available[roomType]}
+		for _, plan := range PublicRatePlans {
+			quote, err := quotePublicRoom(tx, *room, stay, plan, now)
+			if isPromotionError(err) {
+				// the promotion does not apply to this plan; show the plan without it
+				withoutPromotion := stay
+				withoutPromotion.PromotionCode = ""
+				quote, err = quotePublicRoom(tx, *room, withoutPromotion, plan, now)
+			}
+			if errors.Is(err, ErrRatePlanNotFound) || errors.Is(err, ErrMinNights) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			offer.Quotes = append(offer.Quotes, *quote)
+		}
+		if len(offer.Quotes) > 0 {
+			offers = append(offers, offer)
+		}
+	}
+	sort.Slice(offers, func(i, j int) bool { return offers[i].Quotes[0].Total < offers[j].Quotes[0].Total })
+	return offers, nil
+}
+
+// QuotePublicStay prices a stay in a room type under a website rate plan,
+// night by night with any promotion, as it would be booked now
+func QuotePublicStay(tx *gorm.DB, stay PublicStay, now time.Time) (*StayQuote, error) {
+	if err := checkPublicRoomStay(&stay, now); err != nil {
+		return nil, err
+	}
+	room, err := freePublicRoom(tx, stay)
+	if err != nil {
+		return nil, err
+	}
+	return quotePublicRoom(tx, *room, stay, stay.RatePlan, now)
+}
+
+// PublicDeposit is the amount due to confirm a website booking of the
+// total under the rate plan
+func PublicDeposit(total float64, plan RatePlan) float64 {
+	if plan.NonRefundable {
+		return total
+	}
+	return roundMoney(total * PublicDepositPercent / 100)
+}
+
+// CreatePublicBooking holds a room for a website booking as a pending
+// reservation for PublicHoldMinutes. The guest is matched by email or
+// created; an existing guest's details are neither changed nor returned,
+// and the stay is priced as for an unknown guest so no one else's
+// corporate rate is applied. It returns the token the booking is confirmed
+// with, which is shown once.
+func CreatePublicBooking(db *gorm.DB, stay PublicStay, guest PublicGuest, now time.Time) (*Reservation, *StayQuote, string, error) {
+	if err := checkPublicRoomStay(&stay, now); err != nil {
+		return nil, nil, "", err
+	}
+	if strings.TrimSpace(guest.Name) == "" || !strings.Contains(guest.Email, "@") {
+		return nil, nil, "", ErrGuestNameRequired
+	}
+	token, tokenHash, err := newToken()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	var reservation Reservation
+	var quote *StayQuote
+	err = db.Transaction(func(tx *gorm.DB) error {
+		room, err := freePublicRoom(tx, stay)
+		if err != nil {
+			return err
+		}
+		if quote, err = quotePublicRoom(tx, *room, stay, stay.RatePlan, now); err != nil {
+			return err
+		}
+		matched, err := matchGuestByEmail(tx, InboundGuest(guest), now)
+		if err != nil {
+			return err
+		}
+
+		expires := now.Add(PublicHoldMinutes * time.Minute)
+		reservation = Reservation{
+			GuestID:          matched.ID,
+			RoomID:           room.ID,
+			CheckInDate:      stay.CheckInDate,
+			CheckOutDate:     stay.CheckOutDate,
+			Status:           ReservationStatusPending,
+			HoldExpiresAt:    &expires,
+			BookingTokenHash: tokenHash,
+		}
+		if err := applyQuote(tx, &reservation, quote); err != nil {
+			return err
+		}
+		return InsertWithGeneratedID(tx, BookingIDScheme, now, func(id string) { reservation.BookingID = id }, &reservation)
+	})
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return &reservation, quote, token, nil
+}
+
+// ConfirmPublicBooking confirms a held website booking once the deposit has
+// been paid, adding it to the amount paid. Asking again for a booking
+// already confirmed returns it unchanged. An unknown booking ID and a wrong
+// token are reported alike.
+func ConfirmPublicBooking(db *gorm.DB, bookingID, token string, deposit float64, now time.Time) (*Reservation, error) {
+	var reservation *Reservation
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		reservation, err = FindReservationByBookingID(tx, bookingID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrIDChecksum) ||
+			(err == nil && !tokenMatches(token, reservation.BookingTokenHash)) {
+			return ErrBookingNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if reservation.Status == ReservationStatusConfirmed {
+			return nil
+		}
+		if reservation.Status != ReservationStatusPending ||
+			(reservation.HoldExpiresAt != nil && !now.Before(*reservation.HoldExpiresAt)) {
+			return ErrHoldExpired
+		}
+
+		plan, err := FindRatePlan(tx, reservation.RatePlan)
+		if err != nil {
+			return err
+		}
+		due := PublicDeposit(reservation.TotalPrice, plan)
+		if deposit < due {
+			return fmt.Errorf("%w: %.2f due", ErrDepositTooLow, due)
+		}
+		reservation.Status = ReservationStatusConfirmed
+		reservation.PaidAmount = roundMoney(reservation.PaidAmount + deposit)
+		reservation.HoldExpiresAt = nil
+		return tx.Model(reservation).Updates(map[string]interface{}{
+			"status":          ReservationStatusConfirmed,
+			"paid_amount":     reservation.PaidAmount,
+			"hold_expires_at": nil,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reservation, nil
+}
+
+func checkPublicStay(stay *PublicStay, now time.Time) error {
+	stay.CheckInDate, stay.CheckOutDate = atHour(stay.CheckInDate, 0), atHour(stay.CheckOutDate, 0)
+	if !stay.CheckOutDate.After(stay.CheckInDate) || stay.CheckInDate.Before(atHour(now, 0)) {
+		return ErrInvalidStayDates
+	}
+	if stay.CheckOutDate.After(stay.CheckInDate.AddDate(0, 0, PublicMaxNights)) {
+		return fmt.Errorf("%w: at most %d nights", ErrInvalidStayDates, PublicMaxNights)
+	}
+	if stay.Guests < 1 {
+		stay.Guests = 1
+	}
+	if stay.RatePlan == "" {
+		stay.RatePlan = RatePlanBAR
+	}
+	stay.RatePlan = strings.ToUpper(stay.RatePlan)
+	for _, plan := range PublicRatePlans {
+		if plan == stay.RatePlan {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrRatePlanNotPublic, stay.RatePlan)
+}
+
+// checkPublicRoomStay checks a stay to be quoted or booked, which unlike a
+// search must name a room type
+func checkPublicRoomStay(stay *PublicStay, now time.Time) error {
+	if err := checkPublicStay(stay, now); err != nil {
+		return err
+	}
+	stay.RoomType = strings.TrimSpace(stay.RoomType)
+	if stay.RoomType == "" {
+		return ErrRoomTypeRequired
+	}
+	return nil
+}
+
+// freePublicRoom finds a room of the type for the stay. Unlike bookings
+// made by staff, website bookings are never sold against the overbooking
+// allowance.
+func freePublicRoom(tx *gorm.DB, stay PublicStay) (*Room, error) {
+	days, err := InventoryCalendar(tx, stay.CheckInDate, stay.CheckOutDate, stay.RoomType)
+	if err != nil {
+		return nil, err
+	}
+	for _, day := range days {
+		if publicSellable(day) <= 0 {
+			return nil, fmt.Errorf("%w: %s on %s", ErrSoldOut, stay.RoomType, day.Date.Format(dateKey))
+		}
+	}
+	room, err := findFreeRoomOfType(tx, stay.RoomType, stay.Guests, stay.CheckInDate, stay.CheckOutDate, 0)
+	if errors.Is(err, ErrNoRoomAvailable) {
+		return nil, fmt.Errorf("%w: %s", ErrSoldOut, stay.RoomType)
+	}
+	return room, err
+}
+
+// publicSellable is what is left of the night's inventory without the
+// overbooking allowance
+func publicSellable(day InventoryDay) int {
+	if day.Sellable <= day.Allowance {
+		return 0
+	}
+	return day.Sellable - day.Allowance
+}
+
+func quotePublicRoom(tx *gorm.DB, room Room, stay PublicStay, plan string, now time.Time) (*StayQuote, error) {
+	return QuoteGuestStay(tx, StayRequest{
+		RatePlan:      plan,
+		Room:          room,
+		CheckInDate:   stay.CheckInDate,
+		CheckOutDate:  stay.CheckOutDate,
+		Guests:        stay.Guests,
+		PromotionCode: stay.PromotionCode,
+	}, now)
+}
+
+func isPromotionError(err error) bool {
+	return errors.Is(err, ErrPromotionNotValid) || errors.Is(err, ErrPromotionNotEligible) ||
+		errors.Is(err, ErrPromotionUsedUp) || errors.Is(err, ErrPromotionNotCombinable)
+}
@@ -0,0 +1,212 @@
+package models
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// idAlphabet is Crockford's base32: digits and upper-case letters without
+// I, L, O and U, so IDs read aloud or copied by hand are hard to get wrong
+const idAlphabet = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// idMisreads maps characters that are commonly typed in place of an
+// alphabet character onto it
+var idMisreads = strings.NewReplacer("O", "0", "I", "1", "L", "1")
+
+// maxIDAttempts bounds the retries when a generated ID is already taken
+const maxIDAttempts = 5
+
+// Reference ID errors
+var (
+	ErrIDChecksum  = errors.New("reference has a typo: check character does not match")
+	ErrIDExhausted = errors.New("could not generate a unique reference")
+)
+
+// IDScheme describes a human-friendly reference such as BK-250612-7QX4MK:
+// a prefix, an optional date segment and a random segment ending in a check
+// character.
+type IDScheme struct {
+	Prefix       string // upper-case letters, e.g. BK
+	DateLayout   string // Go time layout of digits only, e.g. 060102; empty for no date segment
+	RandomLength int    // random characters, before the check character
+	Checksum     bool   // append a check character that catches single typos and most swaps
+}
+
+// BookingIDScheme generates Reservation.BookingID
+var BookingIDScheme = IDScheme{
+	Prefix:       "BK",
+	DateLayout:   "060102",
+	RandomLength: 5,
+	Checksum:     true,
+}
+
+// OrderIDScheme generates RoomServiceOrder.OrderID
+var OrderIDScheme = IDScheme{
+	Prefix:       "RS",
+	DateLayout:   "060102",
+	RandomLength: 4,
+	Checksum:     true,
+}
+
+// Generate returns a new random ID dated now
+func (s IDScheme) Generate(now time.Time) (string, error) {
+	random := make([]byte, s.RandomLength)
+	max := big.NewInt(int64(len(idAlphabet)))
+	for i := range random {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		random[i] = idAlphabet[n.Int64()]
+	}
+
+	date := ""
+	if s.DateLayout != "" {
+		date = now.Format(s.DateLayout)
+	}
+	body := string(random)
+	if s.Checksum {
+		body += string(checkCharacter(date + body))
+	}
+	return s.format(date, body), nil
+}
+
+// Normalize parses an ID as typed by a person, ignoring case, spaces and
+// dashes and reading O as 0 and I or L as 1, and returns it in canonical
+// form. ok is false if the input does not have this scheme's shape.
+// ErrIDChecksum is returned when it does but the check character is wrong.
+func (s IDScheme) Normalize(input string) (id string, ok bool, err error) {
+	compact := strings.ToUpper(input)
+	compact = strings.NewReplacer(" ", "", "-", "", "\t", "").Replace(compact)
+	if !strings.HasPrefix(compact, s.Prefix) {
+		return "", false, nil
+	}
+	rest := idMisreads.Replace(compact[len(s.Prefix):])
+
+	dateLength := 0
+	if s.DateLayout != "" {
+		dateLength = len(time.Time{}.Format(s.DateLayout))
+	}
+	bodyLength := s.RandomLength
+	if s.Checksum {
+		bodyLength++
+	}
+	if len(rest) != dateLength+bodyLength {
+		return "", false, nil
+	}
+	for _, c := range rest {
+		if !strings.ContainsRune(idAlphabet, c) {
+			return "", false, nil
+		}
+	}
+
+	date, body := rest[:dateLength], rest[dateLength:]
+	if s.DateLayout != "" {
+		if _, err := time.Parse(s.DateLayout, date); err != nil {
+			return "", false, nil
+		}
+	}
+	if s.Checksum {
+		last := len(body) - 1
+		if checkCharacter(date+body[:last]) != body[last] {
+			return "", true, fmt.Errorf("%w: %s", ErrIDChecksum, input)
+		}
+	}
+	return s.format(date, body), true, nil
+}
+
+func (s IDScheme) format(date, body string) string {
+	parts := []string{s.Prefix}
+	if date != "" {
+		parts = append(parts, date)
+	}
+	return strings.Join(append(parts, body), "-")
+}
+
+// checkCharacter computes the Luhn mod 32 check character of value, whose
+// characters must all be in idAlphabet
+func checkCharacter(value string) byte {
+	n := len(idAlphabet)
+	factor, sum := 2, 0
+	for i := len(value) - 1; i >= 0; i-- {
+		addend := factor * strings.IndexByte(idAlphabet, value[i])
+		sum += addend/n + addend%n
+		factor = 3 - factor
+	}
+	return idAlphabet[(n-sum%n)%n]
+}
+
+// InsertWithGeneratedID creates value with a freshly generated ID, which
+// assign copies onto it. If another insert took the same ID first, the
+// unique index rejects the row and a new ID is tried. Each attempt runs in
+// its own savepoint so a conflict does not abort an enclosing transaction.
+func InsertWithGeneratedID(tx *gorm.DB, scheme IDScheme, now time.Time, assign func(id string), value interface{}) error {
+	for attempt := 0; attempt < maxIDAttempts; attempt++ {
+		id, err := scheme.Generate(now)
+		if err != nil {
+			return err
+		}
+		assign(id)
+		err = tx.Transaction(func(tx *gorm.DB) error {
+			return tx.Create(value).Error
+		})
+		if err == nil || !isUniqueViolation(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("%w after %d attempts", ErrIDExhausted, maxIDAttempts)
+}
+
+// isUniqueViolation reports whether err is a unique index conflict. Drivers
+// only return gorm.ErrDuplicatedKey when error translation is enabled, so
+// the PostgreSQL and SQLite messages are recognised as well.
+func isUniqueViolation(err error) bool {
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "SQLSTATE 23505") ||
+		strings.Contains(msg, "duplicate key value") ||
+		strings.Contains(msg, "UNIQUE constraint failed")
+}
+
+// FindReservationByBookingID looks a reservation up by the booking ID a
+// guest or staff member typed. Generated IDs are matched through
+// BookingIDScheme.Normalize; other references, such as group child IDs,
+// are matched ignoring case.
+func FindReservationByBookingID(tx *gorm.DB, input string) (*Reservation, error) {
+	var reservation Reservation
+	if err := findByReference(tx, BookingIDScheme, "booking_id", input, &reservation); err != nil {
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// FindRoomServiceOrderByOrderID looks an order up by its typed order ID
+func FindRoomServiceOrderByOrderID(tx *gorm.DB, input string) (*RoomServiceOrder, error) {
+	var order RoomServiceOrder
+	if err := findByReference(tx, OrderIDScheme, "order_id", input, &order); err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// findByReference loads the first row whose column matches input under
+// scheme. The column name comes from this package, never from user input.
+func findByReference(tx *gorm.DB, scheme IDScheme, column, input string, dest interface{}) error {
+	input = strings.TrimSpace(input)
+	id, ok, err := scheme.Normalize(input)
+	if err != nil {
+		return err
+	}
+	if ok {
+		return tx.Where(column+" = ?", id).First(dest).Error
+	}
+	return tx.Where("UPPER("+column+") = ?", strings.ToUpper(input)).First(dest).Error
+}
@@ -0,0 +1,298 @@
+package models
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Calendar feed kinds, signed into feed URLs
+const (
+	CalendarFeedRoom  = "room"
+	CalendarFeedGuest = "guest"
+)
+
+// CalendarFeedPastDays is how far back a room feed keeps stays after they end
+const CalendarFeedPastDays = 90
+
+// CalendarSettings configures the iCalendar feeds and booking attachments.
+// UIDDomain must not change once feeds are published, or calendar apps see
+// every stay as a new event.
+type CalendarSettings struct {
+	ProductID      string // PRODID of every calendar
+	UIDDomain      string // right-hand side of event UIDs
+	HotelName      string
+	HotelAddress   string // LOCATION of guest events
+	OrganizerEmail string // sender of booking confirmations; no ORGANIZER if empty
+	FeedSecret     []byte // signs feed URLs; changing it revokes every published feed
+}
+
+// Calendar is set at startup
+var Calendar = CalendarSettings{
+	ProductID: "-//Hotel Management System//Reservations//EN",
+	UIDDomain: "reservations.hotel.local",
+	HotelName: "Hotel",
+}
+
+// CalendarAttachment is an .ics file for a booking confirmation email
+type CalendarAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
+// CalendarFeedToken signs the feed of one room or guest, so calendar apps
+// can subscribe without logging in
+func CalendarFeedToken(kind string, id uint) string {
+	mac := hmac.New(sha256.New, Calendar.FeedSecret)
+	fmt.Fprintf(mac, "%s:%d", kind, id)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCalendarFeedToken reports whether the token was issued for the feed.
+// No token is valid until a FeedSecret is set.
+func VerifyCalendarFeedToken(kind string, id uint, token string) bool {
+	if len(Calendar.FeedSecret) == 0 {
+		return false
+	}
+	return hmac.Equal([]byte(CalendarFeedToken(kind, id)), []byte(token))
+}
+
+// RoomCalendar is the occupancy feed of a room: every stay booked in it,
+// including cancelled ones so they are removed from subscribers' calendars,
+// from CalendarFeedPastDays ago onwards. Stays moved to another room stay in
+// the feed as cancelled.
+func RoomCalendar(tx *gorm.DB, roomID uint, now time.Time) ([]byte, error) {
+	var room Room
+	if err := tx.First(&room, roomID).Error; err != nil {
+		return nil, err
+	}
+	var reservations []Reservation
+	movedAway := tx.Model(&ReservationChange{}).Select("reservation_id").Where("old_room_id = ?", roomID)
+	err := tx.Preload("Guest").
+		Where("(room_id = ? OR id IN (?)) AND overbooked = ? AND check_out_date >= ?",
+			roomID, movedAway, false, atHour(now, 0).AddDate(0, 0, -CalendarFeedPastDays)).
+		Order("check_in_date").Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	sequences, err := calendarSequences(tx, reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := newICalendar("Room "+room.RoomNumber, "")
+	for _, reservation := range reservations {
+		description := fmt.Sprintf("Booking %s\n%s, %d nights\nRate plan %s",
+			reservation.BookingID, reservation.Status, reservation.Nights, reservation.RatePlan)
+		if reservation.RoomID != room.ID {
+			reservation.Status = ReservationStatusCancelled
+			description = fmt.Sprintf("Booking %s\nmoved to another room", reservation.BookingID)
+		}
+		reservation.Room = room
+		cal.event(reservation, sequences[reservation.ID], now,
+			fmt.Sprintf("Room %s: %s", room.RoomNumber, reservation.Guest.Name), description, "")
+	}
+	return cal.bytes(), nil
+}
+
+// GuestCalendar is the feed of a guest's own stays, past and future
+func GuestCalendar(tx *gorm.DB, guestID uint, now time.Time) ([]byte, error) {
+	var reservations []Reservation
+	err := tx.Preload("Room").Where("guest_id = ?", guestID).
+		Order("check_in_date").Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	sequences, err := calendarSequences(tx, reservations)
+	if err != nil {
+		return nil, err
+	}
+
+	cal := newICalendar(Calendar.HotelName+" stays", "")
+	for _, reservation := range reservations {
+		cal.guestEvent(reservation, sequences[reservation.ID], now, "")
+	}
+	return cal.bytes(), nil
+}
+
+// ReservationInvite is the .ics attached to a booking confirmation. It has
+// the same UID as the stay in the guest's feed, so sending it again after a
+// change moves the event, and a cancelled or no-show reservation cancels it.
+// Without an OrganizerEmail it is published rather than sent as an invitation.
+func ReservationInvite(tx *gorm.DB, reservation *Reservation, now time.Time) (*CalendarAttachment, error) {
+	if reservation.Room.ID == 0 {
+		if err := tx.First(&reservation.Room, reservation.RoomID).Error; err != nil {
+			return nil, err
+		}
+	}
+	if reservation.Guest.ID == 0 {
+		if err := tx.First(&reservation.Guest, reservation.GuestID).Error; err != nil {
+			return nil, err
+		}
+	}
+	sequences, err := calendarSequences(tx, []Reservation{*reservation})
+	if err != nil {
+		return nil, err
+	}
+
+	method := "PUBLISH"
+	if Calendar.OrganizerEmail != "" {
+		method = "REQUEST"
+		if calendarStatus(reservation.Status) == "CANCELLED" {
+			method = "CANCEL"
+		}
+	}
+	cal := newICalendar("", method)
+	cal.guestEvent(*reservation, sequences[reservation.ID], now, Calendar.OrganizerEmail)
+	return &CalendarAttachment{
+		Filename:    reservation.BookingID + ".ics",
+		ContentType: "text/calendar; charset=utf-8; method=" + method,
+		Content:     cal.bytes(),
+	}, nil
+}
+
+// ReservationEventUID is the stable UID of a reservation's calendar event
+func ReservationEventUID(reservation Reservation) string {
+	return fmt.Sprintf("reservation-%d@%s", reservation.ID, Calendar.UIDDomain)
+}
+
+// calendarSequences numbers each reservation's revisions for SEQUENCE: one
+// for every change of dates or room, plus its status, so that every update
+// a calendar app should apply has a higher number than the last
+func calendarSequences(tx *gorm.DB, reservations []Reservation) (map[uint]int, error) {
+	ids := make([]uint, len(reservations))
+	for i, reservation := range reservations {
+		ids[i] = reservation.ID
+	}
+	var counts []struct {
+		ReservationID uint
+		Changes       int
+	}
+	if len(ids) > 0 {
+		err := tx.Model(&ReservationChange{}).
+			Select("reservation_id, COUNT(*) AS changes").
+			Where("reservation_id IN ?", ids).
+			Group("reservation_id").Scan(&counts).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sequences := make(map[uint]int, len(reservations))
+	for _, count := range counts {
+		sequences[count.ReservationID] = count.Changes
+	}
+	for _, reservation := range reservations {
+		switch calendarStatus(reservation.Status) {
+		case "CONFIRMED":
+			sequences[reservation.ID]++
+		case "CANCELLED":
+			sequences[reservation.ID] += 2
+		}
+	}
+	return sequences, nil
+}
+
+func calendarStatus(status string) string {
+	switch status {
+	case ReservationStatusPending:
+		return "TENTATIVE"
+	case ReservationStatusCancelled, ReservationStatusNoShow:
+		return "CANCELLED"
+	default:
+		return "CONFIRMED"
+	}
+}
+
+// iCalendar writes an RFC 5545 calendar of all-day stay events
+type iCalendar struct {
+	buf bytes.Buffer
+}
+
+func newICalendar(name, method string) *iCalendar {
+	cal := &iCalendar{}
+	cal.line("BEGIN", "VCALENDAR")
+	cal.line("VERSION", "2.0")
+	cal.line("PRODID", Calendar.ProductID)
+	cal.line("CALSCALE", "GREGORIAN")
+	if method != "" {
+		cal.line("METHOD", method)
+	}
+	if name != "" {
+		cal.line("X-WR-CALNAME", icalText(name))
+	}
+	return cal
+}
+
+func (c *iCalendar) guestEvent(reservation Reservation, sequence int, now time.Time, organizer string) {
+	c.event(reservation, sequence, now,
+		"Stay at "+Calendar.HotelName,
+		fmt.Sprintf("Booking %s\n%s room, %d nights\nCheck-in from %02d:00",
+			reservation.BookingID, reservation.Room.RoomType, reservation.Nights, StandardCheckInHour),
+		organizer)
+}
+
+// event writes one stay as an all-day event from the check-in day up to,
+// but not including, the check-out day. With an organizer the guest is
+// added as attendee, as booking confirmations need.
+func (c *iCalendar) event(reservation Reservation, sequence int, now time.Time, summary, description, organizer string) {
+	c.line("BEGIN", "VEVENT")
+	c.line("UID", ReservationEventUID(reservation))
+	c.line("SEQUENCE", strconv.Itoa(sequence))
+	c.line("DTSTAMP", now.UTC().Format("20060102T150405Z"))
+	c.line("LAST-MODIFIED", reservation.UpdatedAt.UTC().Format("20060102T150405Z"))
+	c.line("DTSTART;VALUE=DATE", reservation.CheckInDate.Format("20060102"))
+	c.line("DTEND;VALUE=DATE", reservation.CheckOutDate.Format("20060102"))
+	c.line("SUMMARY", icalText(summary))
+	c.line("DESCRIPTION", icalText(description))
+	if Calendar.HotelAddress != "" {
+		c.line("LOCATION", icalText(Calendar.HotelAddress))
+	}
+	c.line("STATUS", calendarStatus(reservation.Status))
+	c.line("TRANSP", "OPAQUE")
+	if organizer != "" {
+		c.line("ORGANIZER;CN="+icalParam(Calendar.HotelName), "mailto:"+organizer)
+		c.line("ATTENDEE;CN="+icalParam(reservation.Guest.Name)+";ROLE=REQ-PARTICIPANT;RSVP=FALSE",
+			"mailto:"+reservation.Guest.Email)
+	}
+	c.line("END", "VEVENT")
+}
+
+func (c *iCalendar) bytes() []byte {
+	c.line("END", "VCALENDAR")
+	return c.buf.Bytes()
+}
+
+// line writes a content line, folded at 75 octets without splitting a
+// UTF-8 character
+func (c *iCalendar) line(name, value string) {
+	line := name + ":" + value
+	for len(line) > 75 {
+		cut := 75
+		for cut > 0 && line[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		c.buf.WriteString(line[:cut] + "\r\n")
+		line = " " + line[cut:]
+	}
+	c.buf.WriteString(line + "\r\n")
+}
+
+var icalEscaper = strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`)
+
+func icalText(s string) string {
+	return icalEscaper.Replace(s)
+}
+
+// icalParam quotes a parameter value; quotes inside it are not allowed
+func icalParam(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
@@ -0,0 +1,249 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Cancellation and no-show penalty types
+const (
+	PenaltyNone       = "none"
+	PenaltyFirstNight = "first-night"
+	PenaltyPercent    = "percent"
+	PenaltyFullStay   = "full-stay"
+)
+
+// Folio charge types for cancellation and no-show penalties
+const (
+	FolioChargeCancellation = "cancellation"
+	FolioChargeNoShow       = "no-show"
+)
+
+// StandardCheckInHour is the hour of the arrival day that free cancellation
+// windows count back from
+const StandardCheckInHour = 14
+
+// DefaultCancellationPolicy applies when no configured policy matches the
+// reservation: free until 24 hours before check-in, then the first night
+var DefaultCancellationPolicy = CancellationPolicy{
+	Name:                  "Standard",
+	FreeCancellationHours: 24,
+	PenaltyType:           PenaltyFirstNight,
+	NoShowPenaltyType:     PenaltyFirstNight,
+	Active:                true,
+}
+
+// CancellationQuote is what cancelling (or not turning up for) a reservation
+// costs under its policy
+type CancellationQuote struct {
+	Policy    CancellationPolicy `json:"policy"`
+	FreeUntil *time.Time         `json:"free_until"` // nil for non-refundable policies
+	Penalty   float64            `json:"penalty"`
+	Charged   float64            `json:"charged"` // folio charges already posted
+	Paid      float64            `json:"paid"`
+	Refund    float64            `json:"refund"`
+}
+
+// ResolveCancellationPolicy returns the active policy that best matches the
+// reservation's rate plan and room type. A policy naming both beats one
+// naming the rate plan, which beats one naming the room type, which beats a
+// catch-all. DefaultCancellationPolicy is returned if none match.
+func ResolveCancellationPolicy(tx *gorm.DB, reservation *Reservation) (CancellationPolicy, error) {
+	var room Room
+	if err := tx.First(&room, reservation.RoomID).Error; err != nil {
+		return CancellationPolicy{}, err
+	}
+
+	var policies []CancellationPolicy
+	err := tx.Where("active = ? AND rate_plan IN ? AND room_type IN ?",
+		true, []string{"", reservation.RatePlan}, []string{"", room.RoomType}).
+		Order("id").Find(&policies).Error
+	if err != nil {
+		return CancellationPolicy{}, err
+	}
+
+	best, bestScore := DefaultCancellationPolicy, -1
+	for _, policy := range policies {
+		score := 0
+		if policy.RatePlan != "" {
+			score += 2
+		}
+		if policy.RoomType != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = policy, score
+		}
+	}
+	return best, nil
+}
+
+// QuoteCancellation works out the penalty and refund if the reservation were
+// cancelled now. The refund is what the guest has paid less the penalty and
+// any incidental charges already on the folio.
+func QuoteCancellation(tx *gorm.DB, reservation *Reservation, now time.Time) (*CancellationQuote, error) {
+	policy, err := ResolveCancellationPolicy(tx, reservation)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &CancellationQuote{Policy: policy}
+	if policy.NonRefundable {
+		quote.Penalty = penaltyAmount(policy.PenaltyType, policy.PenaltyPercent, reservation)
+	} else {
+		freeUntil := atHour(reservation.CheckInDate, StandardCheckInHour).
+			Add(-time.Duration(policy.FreeCancellationHours) * time.Hour)
+		quote.FreeUntil = &freeUntil
+		if !now.Before(freeUntil) {
+			quote.Penalty = penaltyAmount(policy.PenaltyType, policy.PenaltyPercent, reservation)
+		}
+	}
+	return quote, fillRefund(tx, reservation, quote)
+}
+
+// CancelReservation cancels a pending or confirmed reservation, posting any
+// penalty due under its cancellation policy to the folio and recording the
+// refund owed. Promotion uses are given back, a group's master folio is
+// credited the room it was paying for, and the released room is offered to
+// the waitlist.
+func CancelReservation(db *gorm.DB, reservation *Reservation, reason string, now time.Time) (*CancellationQuote, error) {
+	var quote *CancellationQuote
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if reservation.Status != ReservationStatusPending && reservation.Status != ReservationStatusConfirmed {
+			return fmt.Errorf("%w: cannot cancel a %s reservation", ErrInvalidStatusTransition, reservation.Status)
+		}
+		var err error
+		if quote, err = QuoteCancellation(tx, reservation, now); err != nil {
+			return err
+		}
+		if err := closeReservation(tx, reservation, quote, ReservationStatusCancelled, FolioChargeCancellation, reason, now); err != nil {
+			return err
+		}
+		if err := reverseMasterRoomCharges(tx, reservation, now); err != nil {
+			return err
+		}
+		if err := releasePromotions(tx, reservation.ID); err != nil {
+			return err
+		}
+		return matchReleasedStay(tx, reservation.RoomID, reservation.CheckInDate, reservation.CheckOutDate, now)
+	})
+	return quote, err
+}
+
+// MarkNoShows marks pending and confirmed reservations whose arrival day has
+// passed without a check-in as no-shows, charging each the no-show penalty
+// of its policy. Their remaining nights are offered to the waitlist. It is
+// run nightly, after midnight.
+func MarkNoShows(tx *gorm.DB, now time.Time) ([]Reservation, error) {
+	var reservations []Reservation
+	err := tx.Where("status IN ? AND check_in_date < ?",
+		[]string{ReservationStatusPending, ReservationStatusConfirmed}, atHour(now, 0)).
+		Order("check_in_date, id").Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range reservations {
+		reservation := &reservations[i]
+		policy, err := ResolveCancellationPolicy(tx, reservation)
+		if err != nil {
+			return nil, err
+		}
+		quote := &CancellationQuote{
+			Policy:  policy,
+			Penalty: penaltyAmount(policy.NoShowPenaltyType, policy.NoShowPenaltyPercent, reservation),
+		}
+		if err := fillRefund(tx, reservation, quote); err != nil {
+			return nil, err
+		}
+		if err := closeReservation(tx, reservation, quote, ReservationStatusNoShow, FolioChargeNoShow, "No-show", now); err != nil {
+			return nil, err
+		}
+		if reservation.CheckOutDate.After(atHour(now, 0)) {
+			err := matchReleasedStay(tx, reservation.RoomID, atHour(now, 0), reservation.CheckOutDate, now)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	return reservations, nil
+}
+
+// closeReservation posts the quoted penalty and moves the reservation to
+// its cancelled or no-show status
+func closeReservation(tx *gorm.DB, reservation *Reservation, quote *CancellationQuote, status, chargeType, reason string, now time.Time) error {
+	if quote.Penalty > 0 {
+		charge := FolioCharge{
+			ReservationID: reservation.ID,
+			GuestID:       reservation.GuestID,
+			ChargeType:    chargeType,
+			Description:   fmt.Sprintf("%s penalty (%s), %s", chargeType, quote.Policy.Name, reservation.BookingID),
+			Amount:        quote.Penalty,
+			SourceType:    SourceReservation,
+			SourceID:      reservation.ID,
+			PostedAt:      now,
+		}
+		if err := tx.Create(&charge).Error; err != nil {
+			return err
+		}
+	}
+
+	var policyID *uint
+	if quote.Policy.ID != 0 {
+		policyID = &quote.Policy.ID
+	}
+	reservation.Status = status
+	reservation.CancellationPolicyID = policyID
+	reservation.CancelledAt = &now
+	reservation.CancellationReason = reason
+	reservation.RefundAmount = quote.Refund
+	return tx.Model(reservation).Updates(map[string]interface{}{
+		"status":                 status,
+		"cancellation_policy_id": policyID,
+		"cancelled_at":           now,
+		"cancellation_reason":    reason,
+		"refund_amount":          quote.Refund,
+	}).Error
+}
+
+// fillRefund sets the quote's paid, charged and refund amounts from the
+// reservation's payments and folio. Room charges, such as the difference
+// posted by a stay change, are for nights not taken and are left out.
+func fillRefund(tx *gorm.DB, reservation *Reservation, quote *CancellationQuote) error {
+	var charged float64
+	err := tx.Model(&FolioCharge{}).Where("reservation_id = ? AND charge_type <> ?", reservation.ID, FolioChargeRoom).
+		Select("COALESCE(SUM(amount), 0)").Scan(&charged).Error
+	if err != nil {
+		return err
+	}
+	quote.Paid = reservation.PaidAmount
+	quote.Charged = roundMoney(charged)
+	quote.Refund = roundMoney(quote.Paid - quote.Charged - quote.Penalty)
+	if quote.Refund < 0 {
+		quote.Refund = 0
+	}
+	return nil
+}
+
+// penaltyAmount prices a penalty type against the reservation, never more
+// than the whole stay
+func penaltyAmount(penaltyType string, percent float64, reservation *Reservation) float64 {
+	var amount float64
+	switch penaltyType {
+	case PenaltyFirstNight:
+		amount = reservation.TotalPrice
+		if reservation.Nights > 0 {
+			amount = reservation.TotalPrice / float64(reservation.Nights)
+		}
+	case PenaltyPercent:
+		amount = reservation.TotalPrice * percent / 100
+	case PenaltyFullStay:
+		amount = reservation.TotalPrice
+	}
+	if amount > reservation.TotalPrice {
+		amount = reservation.TotalPrice
+	}
+	return roundMoney(amount)
+}
@@ -0,0 +1,262 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Channel booking statuses. Inbound messages carry the status the channel
+// now has the booking in.
+const (
+	ChannelBookingBooked    = "booked"
+	ChannelBookingModified  = "modified"
+	ChannelBookingCancelled = "cancelled"
+)
+
+// Channel errors
+var (
+	ErrChannelUnauthorized     = errors.New("unknown channel or invalid token")
+	ErrInvalidChannelBooking   = errors.New("invalid channel booking")
+	ErrChannelBookingNotFound  = errors.New("channel booking not found")
+	ErrChannelBookingCancelled = errors.New("channel booking is already cancelled")
+)
+
+// InboundGuest is the guest on a channel booking
+type InboundGuest struct {
+	Name        string `json:"name"`
+	Email       string `json:"email"`
+	Phone       string `json:"phone"`
+	Nationality string `json:"nationality"`
+}
+
+// InboundBooking is a new, modified or cancelled booking sent in by a
+// sales channel
+type InboundBooking struct {
+	ConfirmationNumber string       `json:"confirmation_number"`
+	Status             string       `json:"status"` // booked, modified, cancelled
+	Guest              InboundGuest `json:"guest"`
+	RoomType           string       `json:"room_type"`
+	RatePlan           string       `json:"rate_plan"` // first of the channel's plans if empty
+	CheckInDate        time.Time    `json:"check_in_date"`
+	CheckOutDate       time.Time    `json:"check_out_date"`
+	Guests             int          `json:"guests"`
+	TotalPrice         float64      `json:"total_price"` // as sold; priced under the rate plan if 0
+	PaidAmount         float64      `json:"paid_amount"` // collected by the channel
+}
+
+// NewChannelToken returns a random token for a channel to send bookings
+// with, and the hash to store in TokenHash. The token is shown once.
+func NewChannelToken() (token, hash string, err error) {
+	return newToken()
+}
+
+// AuthenticateChannel loads the active channel with the code if the token
+// is its own
+func AuthenticateChannel(tx *gorm.DB, code, token string) (*SalesChannel, error) {
+	var channel SalesChannel
+	result := tx.Where("code = ? AND active = ?", code, true).Limit(1).Find(&channel)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 || !tokenMatches(token, channel.TokenHash) {
+		return nil, ErrChannelUnauthorized
+	}
+	return &channel, nil
+}
+
+// ImportChannelBooking applies a booking message from the channel. The
+// first message for a confirmation number creates the reservation, matching
+// the guest by email or creating them; later ones change its dates, room
+// type, price or paid amount, or cancel it without penalty. A message that
+// changes nothing, such as a retry or a cancellation of a reservation staff
+// already cancelled, leaves the reservation alone. It reports whether the
+// reservation was created or changed.
+func ImportChannelBooking(db *gorm.DB, channel *SalesChannel, booking InboundBooking, payload string, now time.Time) (*ChannelBooking, bool, error) {
+	booking.ConfirmationNumber = strings.TrimSpace(booking.ConfirmationNumber)
+	if booking.ConfirmationNumber == "" {
+		return nil, false, fmt.Errorf("%w: confirmation number is required", ErrInvalidChannelBooking)
+	}
+	switch booking.Status {
+	case "":
+		booking.Status = ChannelBookingBooked
+	case ChannelBookingBooked, ChannelBookingModified, ChannelBookingCancelled:
+	default:
+		return nil, false, fmt.Errorf("%w: unknown status %q", ErrInvalidChannelBooking, booking.Status)
+	}
+
+	var channelBooking ChannelBooking
+	var changed bool
+	err := db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Preload("Reservation").
+			Where("channel_id = ? AND confirmation_number = ?", channel.ID, booking.ConfirmationNumber).
+			Limit(1).Find(&channelBooking)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		var err error
+		if result.RowsAffected == 0 {
+			if booking.Status == ChannelBookingCancelled {
+				return fmt.Errorf("%w: %s", ErrChannelBookingNotFound, booking.ConfirmationNumber)
+			}
+			changed = true
+			return importNewChannelBooking(tx, channel, booking, payload, &channelBooking, now)
+		}
+		if changed, err = applyChannelUpdate(tx, channel, booking, &channelBooking, now); err != nil {
+			return err
+		}
+		channelBooking.Payload = payload
+		channelBooking.LastMessageAt = now
+		return tx.Model(&channelBooking).Updates(map[string]interface{}{
+			"status":          channelBooking.Status,
+			"payload":         payload,
+			"last_message_at": now,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &channelBooking, changed, nil
+}
+
+func importNewChannelBooking(tx *gorm.DB, channel *SalesChannel, booking InboundBooking, payload string, channelBooking *ChannelBooking, now time.Time) error {
+	if booking.Guest.Name == "" || !strings.Contains(booking.Guest.Email, "@") {
+		return fmt.Errorf("%w: guest name and email are required", ErrInvalidChannelBooking)
+	}
+	guest, err := matchGuestByEmail(tx, booking.Guest, now)
+	if err != nil {
+		return err
+	}
+
+	ratePlan := booking.RatePlan
+	if ratePlan == "" && len(channel.RatePlans) > 0 {
+		ratePlan = fmt.Sprint(channel.RatePlans[0])
+	}
+	reservation := Reservation{
+		GuestID:      guest.ID,
+		CheckInDate:  booking.CheckInDate,
+		CheckOutDate: booking.CheckOutDate,
+		TotalPrice:   booking.TotalPrice,
+		PaidAmount:   booking.PaidAmount,
+		Status:       ReservationStatusConfirmed,
+		RatePlan:     ratePlan,
+		Channel:      channel.Code,
+	}
+	if err := ReserveRoomOfType(tx, &reservation, booking.RoomType, booking.Guests, now); err != nil {
+		return err
+	}
+
+	*channelBooking = ChannelBooking{
+		ChannelID:          channel.ID,
+		ConfirmationNumber: booking.ConfirmationNumber,
+		ReservationID:      reservation.ID,
+		GuestID:            guest.ID,
+		Status:             ChannelBookingBooked,
+		Payload:            payload,
+		ImportedAt:         now,
+		LastMessageAt:      now,
+		Reservation:        reservation,
+	}
+	return tx.Omit("Reservation").Create(channelBooking).Error
+}
+
+// matchGuestByEmail finds the guest with the email, ignoring case, or
+// creates them. An existing guest's details are left as they are.
+func matchGuestByEmail(tx *gorm.DB, inbound InboundGuest, now time.Time) (*Guest, error) {
+	email := strings.ToLower(strings.TrimSpace(inbound.Email))
+	var guest Guest
+	result := tx.Where("LOWER(email) = ?", email).Limit(1).Find(&guest)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return &guest, nil
+	}
+	guest = Guest{
+		Name:        strings.TrimSpace(inbound.Name),
+		Email:       email,
+		Phone:       inbound.Phone,
+		Nationality: inbound.Nationality,
+		JoinDate:    now,
+	}
+	return &guest, tx.Create(&guest).Error
+}
+
+// applyChannelUpdate brings an imported reservation in line with a later
+// message and reports whether anything changed
+func applyChannelUpdate(tx *gorm.DB, channel *SalesChannel, booking InboundBooking, channelBooking *ChannelBooking, now time.Time) (bool, error) {
+	reservation := &channelBooking.Reservation
+	if booking.Status == ChannelBookingCancelled {
+		if channelBooking.Status == ChannelBookingCancelled {
+			return false, nil
+		}
+		if reservation.Status == ReservationStatusCancelled {
+			channelBooking.Status = ChannelBookingCancelled
+			return false, nil
+		}
+		if reservation.Status != ReservationStatusPending && reservation.Status != ReservationStatusConfirmed {
+			return false, fmt.Errorf("%w: %s", ErrReservationClosed, reservation.Status)
+		}
+		if err := releaseHold(tx, reservation, "Cancelled by "+channel.Name, now); err != nil {
+			return false, err
+		}
+		channelBooking.Status = ChannelBookingCancelled
+		return true, nil
+	}
+	if channelBooking.Status == ChannelBookingCancelled {
+		return false, fmt.Errorf("%w: %s", ErrChannelBookingCancelled, booking.ConfirmationNumber)
+	}
+
+	var room Room
+	if err := tx.First(&room, reservation.RoomID).Error; err != nil {
+		return false, err
+	}
+	datesChanged := !booking.CheckInDate.Equal(reservation.CheckInDate) || !booking.CheckOutDate.Equal(reservation.CheckOutDate)
+	roomChanged := booking.RoomType != "" && booking.RoomType != room.RoomType
+	priceChanged := booking.TotalPrice > 0 && roundMoney(booking.TotalPrice) != reservation.TotalPrice
+	paidChanged := booking.PaidAmount > 0 && roundMoney(booking.PaidAmount) != reservation.PaidAmount
+	if !datesChanged && !roomChanged && !priceChanged && !paidChanged {
+		return false, nil
+	}
+
+	if datesChanged || roomChanged || priceChanged {
+		mod := ReservationModification{
+			CheckInDate:  &booking.CheckInDate,
+			CheckOutDate: &booking.CheckOutDate,
+			Reason:       "Modified by " + channel.Name,
+		}
+		if booking.TotalPrice > 0 {
+			mod.TotalPrice = &booking.TotalPrice
+		}
+		roomType := room.RoomType
+		if roomChanged {
+			roomType = booking.RoomType
+		}
+		free, err := RoomIsFree(tx, room.ID, booking.CheckInDate, booking.CheckOutDate, reservation.ID)
+		if err != nil {
+			return false, err
+		}
+		if roomChanged || !free {
+			newRoom, err := findFreeRoomOfType(tx, roomType, booking.Guests, booking.CheckInDate, booking.CheckOutDate, reservation.ID)
+			if err != nil {
+				return false, err
+			}
+			mod.RoomID = &newRoom.ID
+		}
+		if _, err := modifyReservation(tx, reservation, mod, now); err != nil {
+			return false, err
+		}
+	}
+	if paidChanged {
+		reservation.PaidAmount = roundMoney(booking.PaidAmount)
+		if err := tx.Model(reservation).Update("paid_amount", reservation.PaidAmount).Error; err != nil {
+			return false, err
+		}
+	}
+	channelBooking.Status = ChannelBookingModified
+	return true, nil
+}
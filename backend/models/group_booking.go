@@ -0,0 +1,515 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Group booking statuses
+const (
+	GroupStatusActive    = "active"
+	GroupStatusReleased  = "released"
+	GroupStatusCancelled = "cancelled"
+)
+
+// Charge types used only on group master folios
+const (
+	FolioChargeEvent        = "event"
+	FolioChargeFoodBeverage = "food-beverage"
+)
+
+// SourceReservation tags charges created from a reservation
+const SourceReservation = "reservation"
+
+// Group booking errors
+var (
+	ErrGroupNotActive        = errors.New("group booking is not active")
+	ErrInvalidGroupDates     = errors.New("group check-out must be after check-in and release on or before check-in")
+	ErrInsufficientInventory = errors.New("not enough rooms of this type for the dates")
+	ErrNoAllotment           = errors.New("group has no allotment for this room type")
+	ErrAllotmentFull         = errors.New("group allotment for this room type is fully picked up")
+	ErrStayOutsideGroup      = errors.New("stay must fall within the group dates")
+	ErrGuestEmailRequired    = errors.New("guest email is required")
+	ErrNoRoomAvailable       = errors.New("no room of this type is free for the stay")
+)
+
+// CreateGroupBooking stores a group and its allotments after checking that
+// enough rooms of each type are free for the group dates, allowing for
+// reservations and other groups' unclaimed rooms.
+func CreateGroupBooking(db *gorm.DB, group *GroupBooking, allotments []GroupAllotment) error {
+	if !group.CheckOutDate.After(group.CheckInDate) || group.ReleaseDate.After(group.CheckInDate) {
+		return ErrInvalidGroupDates
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, allotment := range allotments {
+			free, err := SellableRoomsOfType(tx, allotment.RoomType, group.CheckInDate, group.CheckOutDate, 0)
+			if err != nil {
+				return err
+			}
+			if free < allotment.Rooms {
+				return fmt.Errorf("%w: %s has %d, %d requested", ErrInsufficientInventory, allotment.RoomType, free, allotment.Rooms)
+			}
+		}
+
+		group.Status = GroupStatusActive
+		group.Allotments = nil
+		if err := tx.Create(group).Error; err != nil {
+			return err
+		}
+		if group.Code == "" {
+			group.Code = fmt.Sprintf("GRP%05d", group.ID)
+			if err := tx.Model(group).Update("code", group.Code).Error; err != nil {
+				return err
+			}
+		}
+		for i := range allotments {
+			allotments[i].GroupBookingID = group.ID
+		}
+		if len(allotments) > 0 {
+			if err := tx.Create(&allotments).Error; err != nil {
+				return err
+			}
+		}
+		group.Allotments = allotments
+		return nil
+	})
+}
+
+// SellableRoomsOfType counts rooms of the type, outside maintenance, with no
+// active reservation overlapping the stay, less the rooms active groups
+// other than excludeGroupID still hold unclaimed.
+func SellableRoomsOfType(tx *gorm.DB, roomType string, checkIn, checkOut time.Time, excludeGroupID uint) (int, error) {
+	var free int64
+	err := tx.Model(&Room{}).
+		Where("room_type = ? AND status <> ?", roomType, RoomStatusMaintenance).
+		Where("NOT EXISTS (SELECT 1 FROM reservations r WHERE r.room_id = rooms.id AND r.status IN ? AND r.check_in_date < ? AND r.check_out_date > ?)",
+			ActiveReservationStatuses, checkOut, checkIn).
+		Count(&free).Error
+	if err != nil {
+		return 0, err
+	}
+
+	held, err := UnclaimedGroupRooms(tx, roomType, checkIn, checkOut, excludeGroupID)
+	if err != nil {
+		return 0, err
+	}
+	return int(free) - held, nil
+}
+
+// UnclaimedGroupRooms returns how many rooms of the type active groups
+// overlapping the stay hold but have not yet picked up
+func UnclaimedGroupRooms(tx *gorm.DB, roomType string, checkIn, checkOut time.Time, excludeGroupID uint) (int, error) {
+	var allotments []GroupAllotment
+	err := tx.Joins("JOIN group_bookings g ON g.id = group_allotments.group_booking_id").
+		Where("group_allotments.room_type = ? AND g.id <> ? AND g.status = ? AND g.check_in_date < ? AND g.check_out_date > ?",
+			roomType, excludeGroupID, GroupStatusActive, checkOut, checkIn).
+		Find(&allotments).Error
+	if err != nil {
+		return 0, err
+	}
+
+	held := 0
+	for _, allotment := range allotments {
+		picked, err := groupPickup(tx, allotment.GroupBookingID, roomType)
+		if err != nil {
+			return 0, err
+		}
+		if allotment.Rooms > picked {
+			held += allotment.Rooms - picked
+		}
+	}
+	return held, nil
+}
+
+// GroupPickup returns how many rooms of each type the group's active child
+// reservations have taken
+func GroupPickup(tx *gorm.DB, groupID uint) (map[string]int, error) {
+	var rows []struct {
+		RoomType string
+		Rooms    int
+	}
+	err := tx.Model(&Reservation{}).
+		Select("rooms.room_type, COUNT(*) AS rooms").
+		Joins("JOIN rooms ON rooms.id = reservations.room_id").
+		Where("reservations.group_booking_id = ? AND reservations.status IN ?", groupID, ActiveReservationStatuses).
+		Group("rooms.room_type").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	pickup := make(map[string]int, len(rows))
+	for _, row := range rows {
+		pickup[row.RoomType] = row.Rooms
+	}
+	return pickup, nil
+}
+
+func groupPickup(tx *gorm.DB, groupID uint, roomType string) (int, error) {
+	var picked int64
+	err := tx.Model(&Reservation{}).
+		Joins("JOIN rooms ON rooms.id = reservations.room_id").
+		Where("reservations.group_booking_id = ? AND reservations.status IN ? AND rooms.room_type = ?",
+			groupID, ActiveReservationStatuses, roomType).
+		Count(&picked).Error
+	return int(picked), err
+}
+
+// RoomingListEntry is one guest on a group's rooming list. Empty dates
+// default to the group dates.
+type RoomingListEntry struct {
+	Name         string     `json:"name"`
+	Email        string     `json:"email"`
+	Phone        string     `json:"phone"`
+	Nationality  string     `json:"nationality"`
+	RoomType     string     `json:"room_type"`
+	CheckInDate  *time.Time `json:"check_in_date"`
+	CheckOutDate *time.Time `json:"check_out_date"`
+}
+
+// RoomingListResult reports what happened to one rooming list entry
+type RoomingListResult struct {
+	Row           int    `json:"row"`
+	Email         string `json:"email"`
+	ReservationID uint   `json:"reservation_id,omitempty"`
+	BookingID     string `json:"booking_id,omitempty"`
+	RoomNumber    string `json:"room_number,omitempty"`
+	Existing      bool   `json:"existing"` // guest already had a reservation in the group
+	Error         string `json:"error,omitempty"`
+}
+
+// ImportRoomingList creates a confirmed child reservation for each entry,
+// matching guests by email and creating those not yet known. Each entry is
+// saved on its own, so one bad row does not stop the rest, and entries for
+// guests already in the group are reported rather than booked twice.
+func ImportRoomingList(db *gorm.DB, group *GroupBooking, entries []RoomingListEntry, now time.Time) []RoomingListResult {
+	results := make([]RoomingListResult, 0, len(entries))
+	for i, entry := range entries {
+		result := RoomingListResult{Row: i + 1, Email: entry.Email}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			return addRoomingListEntry(tx, group, entry, now, &result)
+		})
+		if err != nil {
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func addRoomingListEntry(tx *gorm.DB, group *GroupBooking, entry RoomingListEntry, now time.Time, result *RoomingListResult) error {
+	if group.Status != GroupStatusActive {
+		return ErrGroupNotActive
+	}
+	email := strings.ToLower(strings.TrimSpace(entry.Email))
+	if email == "" {
+		return ErrGuestEmailRequired
+	}
+
+	checkIn, checkOut := group.CheckInDate, group.CheckOutDate
+	if entry.CheckInDate != nil {
+		checkIn = *entry.CheckInDate
+	}
+	if entry.CheckOutDate != nil {
+		checkOut = *entry.CheckOutDate
+	}
+	if checkIn.Before(group.CheckInDate) || checkOut.After(group.CheckOutDate) || !checkOut.After(checkIn) {
+		return ErrStayOutsideGroup
+	}
+
+	var guest Guest
+	found := tx.Where("LOWER(email) = ?", email).Limit(1).Find(&guest)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected == 0 {
+		guest = Guest{
+			Name:        strings.TrimSpace(entry.Name),
+			Email:       email,
+			Phone:       entry.Phone,
+			Nationality: entry.Nationality,
+			JoinDate:    now,
+		}
+		if err := tx.Create(&guest).Error; err != nil {
+			return err
+		}
+	}
+
+	var existing Reservation
+	found = tx.Preload("Room").
+		Where("group_booking_id = ? AND guest_id = ? AND status IN ?", group.ID, guest.ID, ActiveReservationStatuses).
+		Limit(1).Find(&existing)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected > 0 {
+		result.ReservationID = existing.ID
+		result.BookingID = existing.BookingID
+		result.RoomNumber = existing.Room.RoomNumber
+		result.Existing = true
+		return nil
+	}
+
+	var allotment GroupAllotment
+	found = tx.Where("group_booking_id = ? AND room_type = ?", group.ID, entry.RoomType).Limit(1).Find(&allotment)
+	if found.Error != nil {
+		return found.Error
+	}
+	if found.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrNoAllotment, entry.RoomType)
+	}
+	picked, err := groupPickup(tx, group.ID, entry.RoomType)
+	if err != nil {
+		return err
+	}
+	if picked >= allotment.Rooms {
+		return fmt.Errorf("%w: %s", ErrAllotmentFull, entry.RoomType)
+	}
+
+	room, err := findFreeRoomOfType(tx, entry.RoomType, 0, checkIn, checkOut, 0)
+	if err != nil {
+		return err
+	}
+
+	nights := int(math.Round(atHour(checkOut, 0).Sub(atHour(checkIn, 0)).Hours() / 24))
+	groupID := group.ID
+	reservation := Reservation{
+		GuestID:        guest.ID,
+		RoomID:         room.ID,
+		CheckInDate:    checkIn,
+		CheckOutDate:   checkOut,
+		Nights:         nights,
+		TotalPrice:     roundMoney(allotment.RatePerNight * float64(nights)),
+		Status:         ReservationStatusConfirmed,
+		GroupBookingID: &groupID,
+	}
+	err = InsertWithGeneratedID(tx, BookingIDScheme, now, func(id string) { reservation.BookingID = id }, &reservation)
+	if err != nil {
+		return err
+	}
+
+	if group.MasterPaysRoom {
+		reservationID := reservation.ID
+		charge := GroupFolioCharge{
+			GroupBookingID: group.ID,
+			ReservationID:  &reservationID,
+			ChargeType:     FolioChargeRoom,
+			Description:    fmt.Sprintf("Room %s, %s, %d nights (%s)", room.RoomNumber, guest.Name, nights, reservation.BookingID),
+			Amount:         reservation.TotalPrice,
+			SourceType:     SourceReservation,
+			SourceID:       reservation.ID,
+			PostedAt:       now,
+		}
+		if err := tx.Create(&charge).Error; err != nil {
+			return err
+		}
+	}
+
+	result.ReservationID = reservation.ID
+	result.BookingID = reservation.BookingID
+	result.RoomNumber = room.RoomNumber
+	return nil
+}
+
+// masterPaidGroup returns the group of a reservation whose room is charged
+// to the group's master folio, or nil
+func masterPaidGroup(tx *gorm.DB, reservation *Reservation) (*GroupBooking, error) {
+	if reservation.GroupBookingID == nil {
+		return nil, nil
+	}
+	var group GroupBooking
+	if err := tx.First(&group, *reservation.GroupBookingID).Error; err != nil {
+		return nil, err
+	}
+	if !group.MasterPaysRoom {
+		return nil, nil
+	}
+	return &group, nil
+}
+
+// reverseMasterRoomCharges credits back the room charges a cancelled child
+// reservation still has on its group's master folio
+func reverseMasterRoomCharges(tx *gorm.DB, reservation *Reservation, now time.Time) error {
+	group, err := masterPaidGroup(tx, reservation)
+	if err != nil || group == nil {
+		return err
+	}
+	var charged float64
+	err = tx.Model(&GroupFolioCharge{}).
+		Where("group_booking_id = ? AND reservation_id = ? AND charge_type = ?", group.ID, reservation.ID, FolioChargeRoom).
+		Select("COALESCE(SUM(amount), 0)").Scan(&charged).Error
+	if err != nil {
+		return err
+	}
+	if roundMoney(charged) == 0 {
+		return nil
+	}
+	return tx.Create(&GroupFolioCharge{
+		GroupBookingID: group.ID,
+		ReservationID:  &reservation.ID,
+		ChargeType:     FolioChargeRoom,
+		Description:    fmt.Sprintf("Reservation %s cancelled", reservation.BookingID),
+		Amount:         -roundMoney(charged),
+		SourceType:     SourceReservation,
+		SourceID:       reservation.ID,
+		PostedAt:       now,
+	}).Error
+}
+
+// findFreeRoomOfType returns the lowest-numbered room of the type, outside
+// maintenance and sleeping at least minCapacity, that is free for the whole
+// stay apart from excludeReservationID
+func findFreeRoomOfType(tx *gorm.DB, roomType string, minCapacity int, checkIn, checkOut time.Time, excludeReservationID uint) (*Room, error) {
+	var rooms []Room
+	err := tx.Where("room_type = ? AND capacity >= ? AND status <> ?", roomType, minCapacity, RoomStatusMaintenance).
+		Order("floor, room_number").Find(&rooms).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range rooms {
+		free, err := RoomIsFree(tx, rooms[i].ID, checkIn, checkOut, excludeReservationID)
+		if err != nil {
+			return nil, err
+		}
+		if free {
+			return &rooms[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrNoRoomAvailable, roomType)
+}
+
+// ParseRoomingListCSV reads a rooming list with a header row. Recognised
+// columns are name, email, phone, nationality, room_type, check_in and
+// check_out (YYYY-MM-DD); column order does not matter and unknown columns
+// are ignored.
+func ParseRoomingListCSV(r io.Reader) ([]RoomingListEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+	date := func(record []string, name string, line int) (*time.Time, error) {
+		value := field(record, name)
+		if value == "" {
+			return nil, nil
+		}
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid %s %q", line, name, value)
+		}
+		return &parsed, nil
+	}
+
+	var entries []RoomingListEntry
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		entry := RoomingListEntry{
+			Name:        field(record, "name"),
+			Email:       field(record, "email"),
+			Phone:       field(record, "phone"),
+			Nationality: field(record, "nationality"),
+			RoomType:    field(record, "room_type"),
+		}
+		if entry.CheckInDate, err = date(record, "check_in", line); err != nil {
+			return nil, err
+		}
+		if entry.CheckOutDate, err = date(record, "check_out", line); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// PostGroupCharge posts a shared charge, such as a function room or a
+// welcome dinner, to a group's master folio
+func PostGroupCharge(tx *gorm.DB, group *GroupBooking, charge *GroupFolioCharge, now time.Time) error {
+	if group.Status == GroupStatusCancelled {
+		return ErrGroupNotActive
+	}
+	charge.GroupBookingID = group.ID
+	charge.Amount = roundMoney(charge.Amount)
+	if charge.PostedAt.IsZero() {
+		charge.PostedAt = now
+	}
+	return tx.Create(charge).Error
+}
+
+// ReleaseGroupAllotments releases the unclaimed rooms of every active group
+// whose release date has passed, putting them back on general sale. Child
+// reservations already made are kept. It is run daily.
+func ReleaseGroupAllotments(tx *gorm.DB, now time.Time) ([]GroupBooking, error) {
+	var groups []GroupBooking
+	err := tx.Where("status = ? AND release_date <= ?", GroupStatusActive, now).Find(&groups).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		groups[i].Status = GroupStatusReleased
+		groups[i].ReleasedAt = &now
+		err := tx.Model(&groups[i]).Updates(map[string]interface{}{
+			"status":      GroupStatusReleased,
+			"released_at": now,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// CancelGroupBooking cancels the group and every child reservation that has
+// not checked in. Child reservations are not charged cancellation penalties;
+// the group contract governs those. Each is released like an expired hold,
+// so its rooms go to overbooked stays and the waitlist, and its room is
+// credited back to the master folio.
+func CancelGroupBooking(tx *gorm.DB, group *GroupBooking, now time.Time) error {
+	if group.Status == GroupStatusCancelled {
+		return fmt.Errorf("%w: group already cancelled", ErrInvalidStatusTransition)
+	}
+	// The group's holds are lifted first so that the released rooms count as
+	// sellable when they are offered on
+	group.Status = GroupStatusCancelled
+	if err := tx.Model(group).Update("status", GroupStatusCancelled).Error; err != nil {
+		return err
+	}
+	var reservations []Reservation
+	err := tx.Where("group_booking_id = ? AND status IN ?", group.ID,
+		[]string{ReservationStatusPending, ReservationStatusConfirmed}).
+		Order("id").Find(&reservations).Error
+	if err != nil {
+		return err
+	}
+	for i := range reservations {
+		if err := releaseHold(tx, &reservations[i], "Group "+group.Code+" cancelled", now); err != nil {
+			return err
+		}
+		if err := reverseMasterRoomCharges(tx, &reservations[i], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,379 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// GuestLoginLinkMinutes is how long a magic link can be used for
+const GuestLoginLinkMinutes = 15
+
+// GuestSessionDays is how long a guest stays signed in to the portal
+const GuestSessionDays = 30
+
+// GuestLoginLimiter limits magic link requests per email address
+var GuestLoginLimiter = NewRateLimiter(3, 3)
+
+// Guest portal errors
+var (
+	ErrLoginLinkInvalid      = errors.New("login link is invalid, used or expired")
+	ErrGuestSessionInvalid   = errors.New("session is invalid or expired")
+	ErrPreRegistrationClosed = errors.New("reservation can no longer be pre-registered")
+	ErrNoInvoice             = errors.New("no invoice for a pending reservation")
+)
+
+// RequestGuestLoginLink creates a magic link for the guest with the email,
+// ignoring case, and returns the token to email them. For an unknown email
+// it returns no guest and no error, so callers answer the same either way
+// and do not reveal who is a guest.
+func RequestGuestLoginLink(tx *gorm.DB, email string, now time.Time) (*Guest, string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	var guest Guest
+	result := tx.Where("LOWER(email) = ?", email).Limit(1).Find(&guest)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, "", result.Error
+	}
+	token, hash, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	link := GuestLoginLink{
+		GuestID:   guest.ID,
+		TokenHash: hash,
+		ExpiresAt: now.Add(GuestLoginLinkMinutes * time.Minute),
+	}
+	if err := tx.Create(&link).Error; err != nil {
+		return nil, "", err
+	}
+	return &guest, token, nil
+}
+
+// RedeemGuestLoginLink uses up a magic link and signs its guest in,
+// returning the session token, which is shown once
+func RedeemGuestLoginLink(db *gorm.DB, token string, now time.Time) (*GuestSession, string, error) {
+	sessionToken, sessionHash, err := newToken()
+	if err != nil {
+		return nil, "", err
+	}
+	var session GuestSession
+	err = db.Transaction(func(tx *gorm.DB) error {
+		// claimed with a conditional update so a link opened twice at once
+		// signs in only once
+		hash := hashToken(token)
+		result := tx.Model(&GuestLoginLink{}).
+			Where("token_hash = ? AND used_at IS NULL AND expires_at > ?", hash, now).
+			Update("used_at", now)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrLoginLinkInvalid
+		}
+		var link GuestLoginLink
+		if err := tx.Where("token_hash = ?", hash).First(&link).Error; err != nil {
+			return err
+		}
+		session = GuestSession{
+			GuestID:    link.GuestID,
+			TokenHash:  sessionHash,
+			ExpiresAt:  now.AddDate(0, 0, GuestSessionDays),
+			LastSeenAt: now,
+		}
+		return tx.Create(&session).Error
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return &session, sessionToken, nil
+}
+
+// AuthenticateGuestSession loads the guest signed in with the session token
+func AuthenticateGuestSession(tx *gorm.DB, token string, now time.Time) (*Guest, error) {
+	var session GuestSession
+	result := tx.Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", hashToken(token), now).
+		Limit(1).Find(&session)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrGuestSessionInvalid
+	}
+	if err := tx.Model(&session).Update("last_seen_at", now).Error; err != nil {
+		return nil, err
+	}
+	var guest Guest
+	if err := tx.First(&guest, session.GuestID).Error; err != nil {
+		return nil, err
+	}
+	return &guest, nil
+}
+
+// RevokeGuestSession signs the session out
+func RevokeGuestSession(tx *gorm.DB, token string, now time.Time) error {
+	return tx.Model(&GuestSession{}).
+		Where("token_hash = ? AND revoked_at IS NULL", hashToken(token)).
+		Update("revoked_at", now).Error
+}
+
+// GuestStays splits the guest's reservations into upcoming stays, soonest
+// first, and past, cancelled and no-show ones, latest first
+func GuestStays(tx *gorm.DB, guestID uint, now time.Time) (upcoming, past []Reservation, err error) {
+	var reservations []Reservation
+	err = tx.Preload("Room").Preload("Discounts").Where("guest_id = ?", guestID).
+		Order("check_in_date DESC, id DESC").Find(&reservations).Error
+	if err != nil {
+		return nil, nil, err
+	}
+	today := atHour(now, 0)
+	for _, reservation := range reservations {
+		active := false
+		for _, status := range ActiveReservationStatuses {
+			active = active || reservation.Status == status
+		}
+		if active && !reservation.CheckOutDate.Before(today) {
+			upcoming = append([]Reservation{reservation}, upcoming...)
+		} else {
+			past = append(past, reservation)
+		}
+	}
+	return upcoming, past, nil
+}
+
+// FindGuestReservation looks one of the guest's own reservations up by
+// booking ID. Another guest's reservation is reported as not found.
+func FindGuestReservation(tx *gorm.DB, guestID uint, bookingID string) (*Reservation, error) {
+	reservation, err := FindReservationByBookingID(tx, bookingID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, ErrIDChecksum) ||
+		(err == nil && reservation.GuestID != guestID) {
+		return nil, ErrBookingNotFound
+	}
+	return reservation, err
+}
+
+// UpdateGuestPreferences replaces the guest's preferences, creating them
+// the first time
+func UpdateGuestPreferences(tx *gorm.DB, guestID uint, prefs GuestPreferences) (*GuestPreferences, error) {
+	var current GuestPreferences
+	err := tx.Where(GuestPreferences{GuestID: guestID}).
+		Assign(map[string]interface{}{
+			"room_floors":      prefs.RoomFloors,
+			"meal_types":       prefs.MealTypes,
+			"room_types":       prefs.RoomTypes,
+			"special_requests": prefs.SpecialRequests,
+		}).
+		FirstOrCreate(&current).Error
+	if err != nil {
+		return nil, err
+	}
+	return &current, nil
+}
+
+// PreRegisterGuest records the booking guest's ID before arrival, on their
+// occupant record and their profile. Staff still verify it at check-in.
+func PreRegisterGuest(tx *gorm.DB, reservation *Reservation, idType, idNumber, nationality string, now time.Time) (*ReservationOccupant, error) {
+	if err := checkPreRegistrationOpen(reservation, now); err != nil {
+		return nil, err
+	}
+	if idType == "" || idNumber == "" || nationality == "" {
+		return nil, ErrOccupantIDIncomplete
+	}
+	primary, err := EnsurePrimaryOccupant(tx, reservation)
+	if err != nil {
+		return nil, err
+	}
+	if err := UpdateOccupantID(tx, primary, idType, idNumber, nationality); err != nil {
+		return nil, err
+	}
+	err = tx.Model(&Guest{}).Where("id = ?", reservation.GuestID).Updates(map[string]interface{}{
+		"id_type":     idType,
+		"id_number":   idNumber,
+		"nationality": nationality,
+	}).Error
+	return primary, err
+}
+
+// PreRegisterCompanion adds someone travelling with the guest before
+// arrival. Adults need their ID details. A companion already registered
+// under the same name has their ID details updated instead, so the form can
+// be sent again.
+func PreRegisterCompanion(tx *gorm.DB, reservation *Reservation, occupant *ReservationOccupant, now time.Time) error {
+	if err := checkPreRegistrationOpen(reservation, now); err != nil {
+		return err
+	}
+	if occupant.AgeCategory != OccupantChild && !occupantIDComplete(*occupant) {
+		return ErrOccupantIDIncomplete
+	}
+	var existing ReservationOccupant
+	result := tx.Where("reservation_id = ? AND is_primary = ? AND LOWER(name) = ?",
+		reservation.ID, false, strings.ToLower(strings.TrimSpace(occupant.Name))).Limit(1).Find(&existing)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		idType, idNumber, nationality := occupant.IDType, occupant.IDNumber, occupant.Nationality
+		*occupant = existing
+		return UpdateOccupantID(tx, occupant, idType, idNumber, nationality)
+	}
+	occupant.Name = strings.TrimSpace(occupant.Name)
+	occupant.GuestID = nil
+	return AddOccupant(tx, reservation, occupant)
+}
+
+func checkPreRegistrationOpen(reservation *Reservation, now time.Time) error {
+	if reservation.Status != ReservationStatusPending && reservation.Status != ReservationStatusConfirmed {
+		return fmt.Errorf("%w: %s", ErrPreRegistrationClosed, reservation.Status)
+	}
+	if reservation.CheckInDate.Before(atHour(now, 0)) {
+		return fmt.Errorf("%w: arrival day has passed", ErrPreRegistrationClosed)
+	}
+	return nil
+}
+
+// InvoiceLine is one line of an invoice; credits are negative
+type InvoiceLine struct {
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+}
+
+// Invoice is what a guest owes for a reservation
+type Invoice struct {
+	Number       string        `json:"number"`
+	Final        bool          `json:"final"` // the stay is over; otherwise charges so far
+	IssuedAt     time.Time     `json:"issued_at"`
+	BookingID    string        `json:"booking_id"`
+	GuestName    string        `json:"guest_name"`
+	GuestEmail   string        `json:"guest_email"`
+	RoomType     string        `json:"room_type"`
+	CheckInDate  time.Time     `json:"check_in_date"`
+	CheckOutDate time.Time     `json:"check_out_date"`
+	Nights       int           `json:"nights"`
+	Lines        []InvoiceLine `json:"lines"`
+	Total        float64       `json:"total"`
+	Paid         float64       `json:"paid"`
+	Refunded     float64       `json:"refunded"`
+	BalanceDue   float64       `json:"balance_due"` // negative when owed back to the guest
+}
+
+// ReservationInvoice builds the invoice of a reservation: the room, less its
+// discounts, and every folio charge other than room price differences,
+// which the room line already reflects. A cancelled or no-show stay is
+// invoiced for its penalty and extras only, and the room of a group stay
+// is left out when the group's master account pays for it.
+func ReservationInvoice(tx *gorm.DB, reservation *Reservation, now time.Time) (*Invoice, error) {
+	if reservation.Status == ReservationStatusPending {
+		return nil, ErrNoInvoice
+	}
+	var guest Guest
+	if err := tx.First(&guest, reservation.GuestID).Error; err != nil {
+		return nil, err
+	}
+	var room Room
+	if err := tx.First(&room, reservation.RoomID).Error; err != nil {
+		return nil, err
+	}
+	masterPaysRoom := false
+	if reservation.GroupBookingID != nil {
+		var group GroupBooking
+		if err := tx.First(&group, *reservation.GroupBookingID).Error; err != nil {
+			return nil, err
+		}
+		masterPaysRoom = group.MasterPaysRoom
+	}
+	var discounts []ReservationDiscount
+	if err := tx.Where("reservation_id = ?", reservation.ID).Order("id").Find(&discounts).Error; err != nil {
+		return nil, err
+	}
+	var charges []FolioCharge
+	err := tx.Where("reservation_id = ? AND charge_type <> ?", reservation.ID, FolioChargeRoom).
+		Order("posted_at, id").Find(&charges).Error
+	if err != nil {
+		return nil, err
+	}
+
+	closed := calendarStatus(reservation.Status) == "CANCELLED"
+	invoice := &Invoice{
+		Number:       "INV-" + reservation.BookingID,
+		Final:        closed || reservation.Status == ReservationStatusCheckedOut,
+		IssuedAt:     now,
+		BookingID:    reservation.BookingID,
+		GuestName:    guest.Name,
+		GuestEmail:   guest.Email,
+		RoomType:     room.RoomType,
+		CheckInDate:  reservation.CheckInDate,
+		CheckOutDate: reservation.CheckOutDate,
+		Nights:       reservation.Nights,
+		Paid:         reservation.PaidAmount,
+		Refunded:     reservation.RefundAmount,
+	}
+	if !closed && !masterPaysRoom {
+		invoice.Lines = append(invoice.Lines, InvoiceLine{
+			Date:        reservation.CheckInDate,
+			Description: fmt.Sprintf("%s room, %d nights (%s)", room.RoomType, reservation.Nights, reservation.RatePlan),
+			Amount:      roundMoney(reservation.TotalPrice + reservation.DiscountAmount),
+		})
+		for _, discount := range discounts {
+			invoice.Lines = append(invoice.Lines, InvoiceLine{
+				Date:        reservation.CheckInDate,
+				Description: discount.Description,
+				Amount:      -discount.Amount,
+			})
+		}
+	}
+	for _, charge := range charges {
+		invoice.Lines = append(invoice.Lines, InvoiceLine{
+			Date:        charge.PostedAt,
+			Description: charge.Description,
+			Amount:      charge.Amount,
+		})
+	}
+	for _, line := range invoice.Lines {
+		invoice.Total += line.Amount
+	}
+	invoice.Total = roundMoney(invoice.Total)
+	invoice.BalanceDue = roundMoney(invoice.Total - invoice.Paid + invoice.Refunded)
+	return invoice, nil
+}
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"date":  func(t time.Time) string { return t.Format("2 Jan 2006") },
+	"money": func(amount float64) string { return fmt.Sprintf("%.2f", amount) },
+	"abs":   math.Abs,
+}).Parse(`<!DOCTYPE html>
+<html><head><meta charset="utf-8"><title>{{if .Invoice.Final}}Invoice{{else}}Pro forma invoice{{end}} {{.Invoice.Number}}</title>
+<style>body{font-family:sans-serif;max-width:720px;margin:2em auto}table{width:100%;border-collapse:collapse}td,th{padding:4px;border-bottom:1px solid #ddd;text-align:left}.amount{text-align:right}</style>
+</head><body>
+<h1>{{.HotelName}}</h1>{{if .HotelAddress}}<p>{{.HotelAddress}}</p>{{end}}
+<h2>{{if .Invoice.Final}}Invoice{{else}}Pro forma invoice{{end}} {{.Invoice.Number}}</h2>
+<p>Issued {{date .Invoice.IssuedAt}}<br>
+{{.Invoice.GuestName}} &lt;{{.Invoice.GuestEmail}}&gt;<br>
+Booking {{.Invoice.BookingID}}: {{.Invoice.RoomType}} room, {{date .Invoice.CheckInDate}} to {{date .Invoice.CheckOutDate}}</p>
+<table>
+<tr><th>Date</th><th>Description</th><th class="amount">Amount</th></tr>
+{{range .Invoice.Lines}}<tr><td>{{date .Date}}</td><td>{{.Description}}</td><td class="amount">{{money .Amount}}</td></tr>
+{{end}}<tr><th></th><th>Total</th><th class="amount">{{money .Invoice.Total}}</th></tr>
+<tr><td></td><td>Paid</td><td class="amount">{{money .Invoice.Paid}}</td></tr>
+{{if .Invoice.Refunded}}<tr><td></td><td>Refunded</td><td class="amount">{{money .Invoice.Refunded}}</td></tr>
+{{end}}<tr><th></th><th>{{if lt .Invoice.BalanceDue 0.0}}Owed to you{{else}}Balance due{{end}}</th><th class="amount">{{money (abs .Invoice.BalanceDue)}}</th></tr>
+</table>
+</body></html>
+`))
+
+// HTML renders the invoice as a printable page, headed with the hotel name
+// and address from the Calendar settings
+func (inv *Invoice) HTML() ([]byte, error) {
+	var buf bytes.Buffer
+	err := invoiceTemplate.Execute(&buf, map[string]interface{}{
+		"HotelName":    Calendar.HotelName,
+		"HotelAddress": Calendar.HotelAddress,
+		"Invoice":      inv,
+	})
+	return buf.Bytes(), err
+}
@@ -0,0 +1,313 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Housekeeping task types
+const (
+	TaskTypeCheckout     = "checkout"
+	TaskTypeStayOver     = "stay-over"
+	TaskTypeGuestRequest = "guest-request"
+	TaskTypeReClean      = "re-clean"
+)
+
+// Housekeeping task statuses
+const (
+	TaskStatusPending    = "pending"
+	TaskStatusAssigned   = "assigned"
+	TaskStatusInProgress = "in-progress"
+	TaskStatusCleaned    = "cleaned"
+	TaskStatusInspected  = "inspected"
+	TaskStatusFailed     = "failed"
+	TaskStatusCancelled  = "cancelled"
+)
+
+// Estimated minutes per housekeeping task type
+var housekeepingTaskMinutes = map[string]int{
+	TaskTypeCheckout:     45,
+	TaskTypeStayOver:     20,
+	TaskTypeGuestRequest: 15,
+	TaskTypeReClean:      20,
+}
+
+// sameFloorBonus is how many minutes of extra load a housekeeper may carry
+// before a task on a floor they already work is given to someone else.
+const sameFloorBonus = 30
+
+var housekeepingTaskTransitions = map[string][]string{
+	TaskStatusPending:    {TaskStatusAssigned, TaskStatusInProgress, TaskStatusCancelled},
+	TaskStatusAssigned:   {TaskStatusInProgress, TaskStatusCancelled},
+	TaskStatusInProgress: {TaskStatusCleaned},
+}
+
+// HousekeepingWindow returns the time window for a housekeeping request's
+// ScheduleTime on the given day. Unknown values are treated as immediate.
+func HousekeepingWindow(scheduleTime string, day, now time.Time) (time.Time, time.Time) {
+	switch scheduleTime {
+	case "morning":
+		return atHour(day, 8), atHour(day, 12)
+	case "afternoon":
+		return atHour(day, 12), atHour(day, 17)
+	default:
+		return now, now.Add(30 * time.Minute)
+	}
+}
+
+// GenerateDailyHousekeepingTasks creates checkout tasks for rooms whose guests
+// leave on the given day and stay-over tasks for rooms whose guests remain.
+// Rooms that already have a task of that type for the day are skipped, so
+// the job can be re-run safely.
+func GenerateDailyHousekeepingTasks(tx *gorm.DB, day time.Time) ([]HousekeepingTask, error) {
+	dayStart := atHour(day, 0)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var reservations []Reservation
+	err := tx.Preload("Room").
+		Where("status IN ? AND overbooked = ? AND check_in_date < ? AND check_out_date >= ?",
+			[]string{ReservationStatusCheckedIn, ReservationStatusCheckedOut}, false, dayStart, dayStart).
+		Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var existing []HousekeepingTask
+	err = tx.Where("task_date = ? AND task_type IN ? AND status <> ?",
+		dayStart, []string{TaskTypeCheckout, TaskTypeStayOver}, TaskStatusCancelled).
+		Find(&existing).Error
+	if err != nil {
+		return nil, err
+	}
+	scheduled := make(map[string]bool, len(existing))
+	for _, task := range existing {
+		scheduled[fmt.Sprintf("%d/%s", task.RoomID, task.TaskType)] = true
+	}
+
+	var created []HousekeepingTask
+	for _, res := range reservations {
+		var taskType string
+		var start, end time.Time
+		switch {
+		case !res.CheckOutDate.Before(dayStart) && res.CheckOutDate.Before(dayEnd):
+			taskType = TaskTypeCheckout
+			start, end = atHour(day, 11), atHour(day, 14)
+		case res.Status == ReservationStatusCheckedIn && !res.CheckOutDate.Before(dayEnd):
+			taskType = TaskTypeStayOver
+			start, end = atHour(day, 10), atHour(day, 16)
+		default:
+			continue
+		}
+		if scheduled[fmt.Sprintf("%d/%s", res.RoomID, taskType)] {
+			continue
+		}
+
+		reservationID := res.ID
+		task := HousekeepingTask{
+			RoomID:           res.RoomID,
+			ReservationID:    &reservationID,
+			TaskType:         taskType,
+			Status:           TaskStatusPending,
+			TaskDate:         dayStart,
+			WindowStart:      start,
+			WindowEnd:        end,
+			Floor:            res.Room.Floor,
+			EstimatedMinutes: housekeepingTaskMinutes[taskType],
+		}
+		if err := tx.Create(&task).Error; err != nil {
+			return nil, err
+		}
+		scheduled[fmt.Sprintf("%d/%s", res.RoomID, taskType)] = true
+		created = append(created, task)
+	}
+	return created, nil
+}
+
+// ScheduleHousekeepingRequest turns a guest's housekeeping request into a
+// timed task for their room and links the two.
+func ScheduleHousekeepingRequest(tx *gorm.DB, req *HousekeepingRequest, now time.Time) (*HousekeepingTask, error) {
+	var reservation Reservation
+	if err := tx.Preload("Room").First(&reservation, req.ReservationID).Error; err != nil {
+		return nil, err
+	}
+
+	start, end := HousekeepingWindow(req.ScheduleTime, now, now)
+	if end.Before(now) {
+		// The requested slot has already passed today; take tomorrow's.
+		start, end = HousekeepingWindow(req.ScheduleTime, now.AddDate(0, 0, 1), now)
+	}
+
+	requestID := req.ID
+	reservationID := reservation.ID
+	task := HousekeepingTask{
+		RoomID:                reservation.RoomID,
+		ReservationID:         &reservationID,
+		HousekeepingRequestID: &requestID,
+		TaskType:              TaskTypeGuestRequest,
+		Status:                TaskStatusPending,
+		TaskDate:              atHour(start, 0),
+		WindowStart:           start,
+		WindowEnd:             end,
+		Floor:                 reservation.Room.Floor,
+		EstimatedMinutes:      housekeepingTaskMinutes[TaskTypeGuestRequest],
+		Notes:                 req.Description,
+	}
+	if err := tx.Create(&task).Error; err != nil {
+		return nil, err
+	}
+
+	req.TaskID = &task.ID
+	if err := tx.Model(req).Update("task_id", task.ID).Error; err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// AssignHousekeepingTasks spreads the day's unassigned tasks across the
+// housekeepers clocked in. Each task goes to the housekeeper with the
+// lightest load, counting estimated minutes, with a preference for someone
+// already working on the same floor.
+func AssignHousekeepingTasks(tx *gorm.DB, day time.Time) ([]HousekeepingTask, error) {
+	dayStart := atHour(day, 0)
+
+	staff, err := OnDutyStaff(tx, StaffRoleHousekeeping)
+	if err != nil {
+		return nil, err
+	}
+	if len(staff) == 0 {
+		return nil, nil
+	}
+
+	var tasks []HousekeepingTask
+	err = tx.Where("task_date = ? AND status IN ?", dayStart,
+		[]string{TaskStatusPending, TaskStatusAssigned, TaskStatusInProgress}).
+		Order("floor, window_start, id").Find(&tasks).Error
+	if err != nil {
+		return nil, err
+	}
+
+	load := make(map[uint]int, len(staff))
+	floors := make(map[uint]map[int]bool, len(staff))
+	for _, member := range staff {
+		floors[member.ID] = make(map[int]bool)
+	}
+	for _, task := range tasks {
+		if task.AssignedStaffID == nil {
+			continue
+		}
+		if _, ok := floors[*task.AssignedStaffID]; !ok {
+			continue
+		}
+		load[*task.AssignedStaffID] += task.EstimatedMinutes
+		floors[*task.AssignedStaffID][task.Floor] = true
+	}
+
+	// Tasks already in progress only count towards their housekeeper's load;
+	// one started without an assignee is left alone
+	var assigned []HousekeepingTask
+	for _, task := range tasks {
+		if task.AssignedStaffID != nil || task.Status == TaskStatusInProgress {
+			continue
+		}
+
+		cost := func(member Staff) int {
+			if floors[member.ID][task.Floor] {
+				return load[member.ID] - sameFloorBonus
+			}
+			return load[member.ID]
+		}
+		chosen := staff[0]
+		for _, member := range staff[1:] {
+			if cost(member) < cost(chosen) {
+				chosen = member
+			}
+		}
+
+		if err := AssignHousekeepingTask(tx, &task, chosen.ID); err != nil {
+			return nil, err
+		}
+		load[chosen.ID] += task.EstimatedMinutes
+		floors[chosen.ID][task.Floor] = true
+		assigned = append(assigned, task)
+	}
+	return assigned, nil
+}
+
+// AssignHousekeepingTask assigns a task to a housekeeper, and the guest
+// request it was scheduled for along with it
+func AssignHousekeepingTask(tx *gorm.DB, task *HousekeepingTask, staffID uint) error {
+	if task.Status != TaskStatusPending && task.Status != TaskStatusAssigned {
+		return fmt.Errorf("%w: cannot assign %s task", ErrInvalidStatusTransition, task.Status)
+	}
+	task.AssignedStaffID = &staffID
+	task.Status = TaskStatusAssigned
+	err := tx.Model(task).Updates(map[string]interface{}{
+		"assigned_staff_id": staffID,
+		"status":            TaskStatusAssigned,
+	}).Error
+	if err != nil || task.HousekeepingRequestID == nil {
+		return err
+	}
+	return tx.Model(&HousekeepingRequest{}).Where("id = ?", *task.HousekeepingRequestID).
+		Update("assigned_staff_id", staffID).Error
+}
+
+// AdvanceHousekeepingTask moves a task through the room-turn workflow up to
+// "cleaned" and keeps the linked guest request in step. A cleaned task is
+// only inspected or failed through RecordRoomInspection.
+func AdvanceHousekeepingTask(tx *gorm.DB, task *HousekeepingTask, status string, actorID *uint, now time.Time) error {
+	if !canTransition(housekeepingTaskTransitions, task.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, task.Status, status)
+	}
+	return advanceHousekeepingTask(tx, task, status, actorID, now)
+}
+
+// advanceHousekeepingTask sets the task's status without checking the
+// transition. Completing the inspection releases a room that is in
+// "cleaning" back to "available".
+func advanceHousekeepingTask(tx *gorm.DB, task *HousekeepingTask, status string, actorID *uint, now time.Time) error {
+	updates := map[string]interface{}{"status": status}
+	requestUpdates := map[string]interface{}{}
+	switch status {
+	case TaskStatusInProgress:
+		updates["started_at"] = now
+		task.StartedAt = &now
+		requestUpdates["status"] = RequestStatusInProgress
+	case TaskStatusCleaned:
+		updates["cleaned_at"] = now
+		task.CleanedAt = &now
+		requestUpdates["status"] = RequestStatusCompleted
+		requestUpdates["completed_at"] = now
+	case TaskStatusInspected, TaskStatusFailed:
+		updates["inspected_at"] = now
+		updates["inspected_by_id"] = actorID
+		task.InspectedAt = &now
+		task.InspectedByID = actorID
+	}
+	task.Status = status
+	if err := tx.Model(task).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if task.HousekeepingRequestID != nil && len(requestUpdates) > 0 {
+		err := tx.Model(&HousekeepingRequest{}).Where("id = ?", *task.HousekeepingRequestID).
+			Updates(requestUpdates).Error
+		if err != nil {
+			return err
+		}
+	}
+
+	if status == TaskStatusInspected {
+		return tx.Model(&Room{}).
+			Where("id = ? AND status = ?", task.RoomID, RoomStatusCleaning).
+			Update("status", RoomStatusAvailable).Error
+	}
+	return nil
+}
+
+// atHour returns the given hour on t's calendar day, in t's location
+func atHour(t time.Time, hour int) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), hour, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,185 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Stock movement reasons
+const (
+	StockReasonOrder      = "order"
+	StockReasonRestock    = "restock"
+	StockReasonAdjustment = "adjustment"
+	StockReasonWaste      = "waste"
+)
+
+// Low stock alert statuses
+const (
+	AlertStatusOpen     = "open"
+	AlertStatusResolved = "resolved"
+)
+
+// AdjustIngredientStock applies a stock change to one ingredient and records
+// it as a StockMovement. Dropping to the low-stock threshold raises an alert
+// and running out marks every menu item that uses the ingredient out of
+// stock; going back above zero or the threshold reverses both.
+func AdjustIngredientStock(tx *gorm.DB, ingredientID uint, change float64, reason, sourceType string, sourceID uint, notes string, now time.Time) (*Ingredient, error) {
+	err := tx.Model(&Ingredient{}).Where("id = ?", ingredientID).
+		Update("stock_quantity", gorm.Expr("stock_quantity + ?", change)).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var ingredient Ingredient
+	if err := tx.First(&ingredient, ingredientID).Error; err != nil {
+		return nil, err
+	}
+
+	movement := StockMovement{
+		IngredientID: ingredientID,
+		Change:       change,
+		BalanceAfter: ingredient.StockQuantity,
+		Reason:       reason,
+		SourceType:   sourceType,
+		SourceID:     sourceID,
+		Notes:        notes,
+		CreatedAt:    now,
+	}
+	if err := tx.Create(&movement).Error; err != nil {
+		return nil, err
+	}
+
+	if err := syncLowStockAlert(tx, ingredient, now); err != nil {
+		return nil, err
+	}
+	if err := syncMenuAvailability(tx, ingredient); err != nil {
+		return nil, err
+	}
+	return &ingredient, nil
+}
+
+// DepleteStockForOrder deducts the recipe quantities of every line on a
+// delivered order. It is safe to call more than once for the same order.
+func DepleteStockForOrder(tx *gorm.DB, order *RoomServiceOrder, now time.Time) error {
+	var done int64
+	err := tx.Model(&StockMovement{}).
+		Where("source_type = ? AND source_id = ?", SourceRoomServiceOrder, order.ID).
+		Count(&done).Error
+	if err != nil {
+		return err
+	}
+	if done > 0 {
+		return nil
+	}
+
+	var lines []RoomServiceOrderLine
+	if err := tx.Where("room_service_order_id = ?", order.ID).Find(&lines).Error; err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	quantities := make(map[uint]int)
+	menuItemIDs := make([]uint, 0, len(lines))
+	for _, line := range lines {
+		if _, ok := quantities[line.MenuItemID]; !ok {
+			menuItemIDs = append(menuItemIDs, line.MenuItemID)
+		}
+		quantities[line.MenuItemID] += line.Quantity
+	}
+
+	var recipe []RecipeItem
+	if err := tx.Where("menu_item_id IN ?", menuItemIDs).Order("ingredient_id").Find(&recipe).Error; err != nil {
+		return err
+	}
+
+	usage := make(map[uint]float64)
+	var ingredientIDs []uint
+	for _, item := range recipe {
+		if _, ok := usage[item.IngredientID]; !ok {
+			ingredientIDs = append(ingredientIDs, item.IngredientID)
+		}
+		usage[item.IngredientID] += item.Quantity * float64(quantities[item.MenuItemID])
+	}
+
+	for _, id := range ingredientIDs {
+		_, err := AdjustIngredientStock(tx, id, -usage[id], StockReasonOrder,
+			SourceRoomServiceOrder, order.ID, "Order "+order.OrderID, now)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncLowStockAlert opens an alert when the ingredient is at or below its
+// threshold and resolves the open alert once it is back above it.
+func syncLowStockAlert(tx *gorm.DB, ingredient Ingredient, now time.Time) error {
+	var open LowStockAlert
+	result := tx.Where("ingredient_id = ? AND status = ?", ingredient.ID, AlertStatusOpen).Limit(1).Find(&open)
+	if result.Error != nil {
+		return result.Error
+	}
+	hasOpen := result.RowsAffected > 0
+	low := ingredient.StockQuantity <= ingredient.LowStockThreshold
+
+	switch {
+	case low && !hasOpen:
+		alert := LowStockAlert{
+			IngredientID:  ingredient.ID,
+			StockQuantity: ingredient.StockQuantity,
+			Threshold:     ingredient.LowStockThreshold,
+			Status:        AlertStatusOpen,
+			RaisedAt:      now,
+		}
+		return tx.Create(&alert).Error
+	case low && hasOpen:
+		return tx.Model(&open).Update("stock_quantity", ingredient.StockQuantity).Error
+	case !low && hasOpen:
+		return tx.Model(&open).Updates(map[string]interface{}{
+			"status":      AlertStatusResolved,
+			"resolved_at": now,
+		}).Error
+	}
+	return nil
+}
+
+// syncMenuAvailability marks menu items that use the ingredient out of stock
+// when it runs out, and clears the mark once all of their ingredients are in
+// stock again. Available is left to the manager, so an item switched off by
+// hand stays off after a restock.
+func syncMenuAvailability(tx *gorm.DB, ingredient Ingredient) error {
+	menuItems := tx.Model(&RecipeItem{}).Select("menu_item_id").Where("ingredient_id = ?", ingredient.ID)
+
+	if ingredient.StockQuantity <= 0 {
+		return tx.Model(&MenuItem{}).Where("id IN (?)", menuItems).
+			Update("out_of_stock", true).Error
+	}
+
+	var candidates []MenuItem
+	err := tx.Preload("Recipe.Ingredient").
+		Where("id IN (?) AND out_of_stock = ?", menuItems, true).
+		Find(&candidates).Error
+	if err != nil {
+		return err
+	}
+	for _, item := range candidates {
+		inStock := true
+		for _, r := range item.Recipe {
+			if r.Ingredient.StockQuantity <= 0 {
+				inStock = false
+				break
+			}
+		}
+		if !inStock {
+			continue
+		}
+		err := tx.Model(&MenuItem{}).Where("id = ?", item.ID).Update("out_of_stock", false).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
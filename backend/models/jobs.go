@@ -0,0 +1,26 @@
+package models
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// RunPeriodically calls job every interval until ctx is cancelled. It is
+// used for background jobs such as SLA escalation and is meant to be started
+// in its own goroutine from main. Errors are logged and do not stop the loop.
+func RunPeriodically(ctx context.Context, name string, interval time.Duration, job func(now time.Time) error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if err := job(now); err != nil {
+				log.Printf("%s: %v", name, err)
+			}
+		}
+	}
+}
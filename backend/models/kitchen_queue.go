@@ -0,0 +1,359 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Room service order statuses
+const (
+	OrderStatusPending        = "pending"
+	OrderStatusAccepted       = "accepted"
+	OrderStatusCooking        = "cooking"
+	OrderStatusReady          = "ready"
+	OrderStatusOutForDelivery = "out-for-delivery"
+	OrderStatusDelivered      = "delivered"
+	OrderStatusCancelled      = "cancelled"
+)
+
+// Kitchen ticket statuses
+const (
+	TicketStatusPending   = "pending"
+	TicketStatusAccepted  = "accepted"
+	TicketStatusCooking   = "cooking"
+	TicketStatusReady     = "ready"
+	TicketStatusCancelled = "cancelled"
+)
+
+// Kitchen queue errors
+var (
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+)
+
+// DefaultDeliveryMinutes is the time allowed to carry a ready order to the room
+const DefaultDeliveryMinutes = 10
+
+// prepHistorySize is how many recent tickets feed a station's prep estimate,
+// and prepHistoryMin how many are needed before the default is replaced.
+const (
+	prepHistorySize = 50
+	prepHistoryMin  = 5
+)
+
+var roomServiceOrderTransitions = map[string][]string{
+	OrderStatusPending:        {OrderStatusAccepted, OrderStatusCancelled},
+	OrderStatusAccepted:       {OrderStatusCooking, OrderStatusCancelled},
+	OrderStatusCooking:        {OrderStatusReady},
+	OrderStatusReady:          {OrderStatusOutForDelivery},
+	OrderStatusOutForDelivery: {OrderStatusDelivered},
+}
+
+var kitchenTicketTransitions = map[string][]string{
+	TicketStatusPending:  {TicketStatusAccepted, TicketStatusCancelled},
+	TicketStatusAccepted: {TicketStatusCooking, TicketStatusCancelled},
+	TicketStatusCooking:  {TicketStatusReady},
+}
+
+func canTransition(transitions map[string][]string, from, to string) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// QueueRoomServiceOrder splits a priced order into one kitchen ticket per
+// station, routing each line by its menu category, and sets the order's
+// estimated delivery time. Lines whose category has no route go to the
+// first active station. Without an active station the order is not ticketed
+// and only the delivery time is estimated.
+func QueueRoomServiceOrder(tx *gorm.DB, order *RoomServiceOrder, now time.Time) error {
+	var stations []KitchenStation
+	if err := tx.Preload("Routes").Where("active = ?", true).Order("id").Find(&stations).Error; err != nil {
+		return err
+	}
+	if len(stations) == 0 {
+		eta := now.Add(DefaultDeliveryMinutes * time.Minute)
+		order.EstimatedDeliveryAt = &eta
+		return tx.Model(order).Update("estimated_delivery_at", eta).Error
+	}
+
+	stationFor := make(map[string]KitchenStation)
+	for _, station := range stations {
+		for _, route := range station.Routes {
+			stationFor[route.Category] = station
+		}
+	}
+
+	tickets := make(map[uint]*KitchenTicket)
+	lines := make(map[uint][]int)
+	var ticketOrder []uint
+	for i := range order.Lines {
+		station, ok := stationFor[order.Lines[i].Category]
+		if !ok {
+			station = stations[0]
+		}
+		if _, ok := tickets[station.ID]; !ok {
+			tickets[station.ID] = &KitchenTicket{
+				RoomServiceOrderID: order.ID,
+				StationID:          station.ID,
+				Status:             TicketStatusPending,
+				QueuedAt:           now,
+			}
+			ticketOrder = append(ticketOrder, station.ID)
+		}
+		lines[station.ID] = append(lines[station.ID], i)
+	}
+
+	byID := make(map[uint]KitchenStation, len(stations))
+	for _, station := range stations {
+		byID[station.ID] = station
+	}
+
+	var longest time.Duration
+	for _, stationID := range ticketOrder {
+		wait, err := EstimateStationWait(tx, byID[stationID])
+		if err != nil {
+			return err
+		}
+		if wait > longest {
+			longest = wait
+		}
+
+		ticket := tickets[stationID]
+		ticket.EstimatedPrepMins = int(wait / time.Minute)
+		if err := tx.Create(ticket).Error; err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(lines[stationID]))
+		for _, i := range lines[stationID] {
+			order.Lines[i].KitchenTicketID = &ticket.ID
+			ids = append(ids, order.Lines[i].ID)
+		}
+		err = tx.Model(&RoomServiceOrderLine{}).Where("id IN ?", ids).
+			Update("kitchen_ticket_id", ticket.ID).Error
+		if err != nil {
+			return err
+		}
+		order.Tickets = append(order.Tickets, *ticket)
+	}
+
+	eta := now.Add(longest + DefaultDeliveryMinutes*time.Minute)
+	order.EstimatedDeliveryAt = &eta
+	return tx.Model(order).Update("estimated_delivery_at", eta).Error
+}
+
+// EstimateStationPrepMinutes averages accepted-to-ready time over the
+// station's most recent tickets, falling back to the station default while
+// there is not enough history.
+func EstimateStationPrepMinutes(tx *gorm.DB, station KitchenStation) (int, error) {
+	var history []KitchenTicket
+	err := tx.Where("station_id = ? AND status = ? AND accepted_at IS NOT NULL AND ready_at IS NOT NULL",
+		station.ID, TicketStatusReady).
+		Order("ready_at DESC").Limit(prepHistorySize).Find(&history).Error
+	if err != nil {
+		return 0, err
+	}
+	if len(history) < prepHistoryMin {
+		return station.DefaultPrepMinutes, nil
+	}
+
+	var total time.Duration
+	for _, ticket := range history {
+		total += ticket.ReadyAt.Sub(*ticket.AcceptedAt)
+	}
+	return int((total/time.Duration(len(history)) + time.Minute - 1) / time.Minute), nil
+}
+
+// EstimateStationWait returns how long a ticket queued now will take at the
+// station, counting the tickets already ahead of it.
+func EstimateStationWait(tx *gorm.DB, station KitchenStation) (time.Duration, error) {
+	prep, err := EstimateStationPrepMinutes(tx, station)
+	if err != nil {
+		return 0, err
+	}
+
+	var ahead int64
+	err = tx.Model(&KitchenTicket{}).
+		Where("station_id = ? AND status IN ?", station.ID,
+			[]string{TicketStatusPending, TicketStatusAccepted, TicketStatusCooking}).
+		Count(&ahead).Error
+	if err != nil {
+		return 0, err
+	}
+
+	capacity := station.Capacity
+	if capacity < 1 {
+		capacity = 1
+	}
+	batches := int(ahead)/capacity + 1
+	return time.Duration(prep*batches) * time.Minute, nil
+}
+
+// AdvanceKitchenTicket moves a ticket to the given status and rolls the
+// change up to its order: the first accepted or cooking ticket advances the
+// order, and the order becomes ready once every ticket is ready or cancelled.
+// When every ticket is cancelled the order is cancelled and credited.
+func AdvanceKitchenTicket(tx *gorm.DB, ticket *KitchenTicket, status string, now time.Time) error {
+	if !canTransition(kitchenTicketTransitions, ticket.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, ticket.Status, status)
+	}
+
+	ticket.Status = status
+	switch status {
+	case TicketStatusAccepted:
+		ticket.AcceptedAt = &now
+	case TicketStatusCooking:
+		ticket.CookingAt = &now
+	case TicketStatusReady:
+		ticket.ReadyAt = &now
+	}
+	if err := tx.Omit("Station", "Lines").Save(ticket).Error; err != nil {
+		return err
+	}
+
+	var order RoomServiceOrder
+	if err := tx.Preload("Tickets").First(&order, ticket.RoomServiceOrderID).Error; err != nil {
+		return err
+	}
+
+	next := order.Status
+	switch status {
+	case TicketStatusAccepted:
+		if order.Status == OrderStatusPending {
+			next = OrderStatusAccepted
+		}
+	case TicketStatusCooking:
+		if order.Status == OrderStatusPending || order.Status == OrderStatusAccepted {
+			next = OrderStatusCooking
+		}
+	case TicketStatusReady, TicketStatusCancelled:
+		allReady, allCancelled := true, true
+		for _, t := range order.Tickets {
+			if t.Status != TicketStatusReady && t.Status != TicketStatusCancelled {
+				allReady = false
+			}
+			if t.Status != TicketStatusCancelled {
+				allCancelled = false
+			}
+		}
+		if allCancelled {
+			return AdvanceRoomServiceOrder(tx, &order, OrderStatusCancelled, now)
+		}
+		if allReady {
+			next = OrderStatusReady
+		}
+	}
+	if next == order.Status {
+		return nil
+	}
+
+	updates := map[string]interface{}{"status": next}
+	switch next {
+	case OrderStatusAccepted:
+		updates["accepted_at"] = now
+	case OrderStatusCooking:
+		if order.AcceptedAt == nil {
+			updates["accepted_at"] = now
+		}
+	case OrderStatusReady:
+		updates["ready_at"] = now
+	}
+	return tx.Model(&order).Updates(updates).Error
+}
+
+// AdvanceRoomServiceOrder moves an order to the given status. Kitchen
+// statuses are normally reached through AdvanceKitchenTicket; this is used
+// for dispatch, delivery and cancellation. Delivering depletes ingredient
+// stock. Cancelling also cancels any tickets the kitchen has not started and
+// credits the order's folio charge back to the reservation.
+func AdvanceRoomServiceOrder(tx *gorm.DB, order *RoomServiceOrder, status string, now time.Time) error {
+	if !canTransition(roomServiceOrderTransitions, order.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, order.Status, status)
+	}
+
+	updates := map[string]interface{}{"status": status}
+	switch status {
+	case OrderStatusAccepted:
+		updates["accepted_at"] = now
+	case OrderStatusReady:
+		updates["ready_at"] = now
+	case OrderStatusOutForDelivery:
+		updates["dispatched_at"] = now
+		eta := now.Add(DefaultDeliveryMinutes * time.Minute)
+		updates["estimated_delivery_at"] = eta
+	case OrderStatusDelivered:
+		updates["delivered_at"] = now
+		if err := DepleteStockForOrder(tx, order, now); err != nil {
+			return err
+		}
+	case OrderStatusCancelled:
+		err := tx.Model(&KitchenTicket{}).
+			Where("room_service_order_id = ? AND status IN ?", order.ID,
+				[]string{TicketStatusPending, TicketStatusAccepted}).
+			Update("status", TicketStatusCancelled).Error
+		if err != nil {
+			return err
+		}
+		if err := creditRoomServiceCharge(tx, order, now); err != nil {
+			return err
+		}
+	}
+	return tx.Model(order).Updates(updates).Error
+}
+
+// creditRoomServiceCharge posts a negative room-service charge for whatever
+// the order still has on the folio, so a cancelled order nets to zero.
+// Loyalty points redeemed against it are given back first.
+func creditRoomServiceCharge(tx *gorm.DB, order *RoomServiceOrder, now time.Time) error {
+	var chargeIDs []uint
+	err := tx.Model(&FolioCharge{}).
+		Where("source_type = ? AND source_id = ?", SourceRoomServiceOrder, order.ID).
+		Pluck("id", &chargeIDs).Error
+	if err != nil {
+		return err
+	}
+	if err := refundRedemptions(tx, chargeIDs, "order cancelled", now); err != nil {
+		return err
+	}
+
+	var charged float64
+	err = tx.Model(&FolioCharge{}).
+		Where("source_type = ? AND source_id = ?", SourceRoomServiceOrder, order.ID).
+		Select("COALESCE(SUM(amount), 0)").Scan(&charged).Error
+	if err != nil {
+		return err
+	}
+	if roundMoney(charged) == 0 {
+		return nil
+	}
+	credit := FolioCharge{
+		ReservationID: order.ReservationID,
+		GuestID:       order.GuestID,
+		ChargeType:    FolioChargeRoomService,
+		Description:   "Room service order " + order.OrderID + " cancelled",
+		Amount:        -roundMoney(charged),
+		SourceType:    SourceRoomServiceOrder,
+		SourceID:      order.ID,
+		PostedAt:      now,
+	}
+	return tx.Create(&credit).Error
+}
+
+// ETAMinutes returns the minutes left until the estimated delivery time, or
+// zero once delivered or when no estimate exists.
+func (o RoomServiceOrder) ETAMinutes(now time.Time) int {
+	if o.EstimatedDeliveryAt == nil || o.DeliveredAt != nil {
+		return 0
+	}
+	remaining := o.EstimatedDeliveryAt.Sub(now)
+	if remaining <= 0 {
+		return 0
+	}
+	return int((remaining + time.Minute - 1) / time.Minute)
+}
@@ -0,0 +1,473 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Loyalty tiers. Guests below every configured tier are members.
+const (
+	LoyaltyTierMember   = "member"
+	LoyaltyTierSilver   = "silver"
+	LoyaltyTierGold     = "gold"
+	LoyaltyTierPlatinum = "platinum"
+)
+
+// Loyalty ledger entry types
+const (
+	LoyaltyEarnStay        = "earn-stay"
+	LoyaltyEarnRoomService = "earn-room-service"
+	LoyaltyRedeem          = "redeem"
+	LoyaltyRefund          = "refund" // points given back when what they paid for is cancelled
+	LoyaltyAdjust          = "adjust"
+)
+
+// Loyalty programme rules
+const (
+	LoyaltyPointsPerNight = 100  // per room night
+	LoyaltyPointsPerSpend = 1    // per 1.00 of room service
+	LoyaltyPointValue     = 0.01 // folio credit per point redeemed
+	LoyaltyWindowMonths   = 12   // tier qualification window
+)
+
+// FolioChargeLoyalty is the charge type of a redemption credit, posted
+// against the charge it pays for under SourceFolioCharge
+const (
+	FolioChargeLoyalty = "loyalty"
+	SourceFolioCharge  = "folio_charge"
+)
+
+// DefaultLoyaltyTiers apply when no tiers have been configured
+var DefaultLoyaltyTiers = []LoyaltyTier{
+	{Name: LoyaltyTierSilver, Rank: 1, MinStays: 3, MinSpend: 1500, BonusPercent: 10, Active: true},
+	{Name: LoyaltyTierGold, Rank: 2, MinStays: 8, MinSpend: 5000, BonusPercent: 25, Active: true},
+	{Name: LoyaltyTierPlatinum, Rank: 3, MinStays: 15, MinSpend: 12000, BonusPercent: 50, Active: true},
+}
+
+// Loyalty errors
+var (
+	ErrInsufficientPoints = errors.New("not enough loyalty points")
+	ErrNothingToRedeem    = errors.New("folio charge has nothing left to redeem against")
+	ErrFolioClosed        = errors.New("reservation folio is closed")
+)
+
+// LoyaltyStatus is where a guest stands in the loyalty programme
+type LoyaltyStatus struct {
+	Tier            string    `json:"tier"`
+	Points          int       `json:"points"`
+	PointsValue     float64   `json:"points_value"` // folio credit the balance is worth
+	WindowStart     time.Time `json:"window_start"`
+	Stays           int       `json:"stays"` // within the window
+	Spend           float64   `json:"spend"` // within the window
+	NextTier        string    `json:"next_tier,omitempty"`
+	StaysToNextTier int       `json:"stays_to_next_tier"`
+	SpendToNextTier float64   `json:"spend_to_next_tier"`
+}
+
+// CheckOutReservation checks a checked-in reservation out: it records the
+// check-out, marks the reservation checked out and the room for cleaning,
+// and awards the guest's loyalty points for the stay.
+func CheckOutReservation(db *gorm.DB, checkOut *CheckOut, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var reservation Reservation
+		if err := tx.First(&reservation, checkOut.ReservationID).Error; err != nil {
+			return err
+		}
+		if reservation.Status != ReservationStatusCheckedIn {
+			return fmt.Errorf("%w: %s", ErrReservationNotReady, reservation.Status)
+		}
+
+		checkOut.GuestID = reservation.GuestID
+		checkOut.RoomID = reservation.RoomID
+		if checkOut.CheckOutTime.IsZero() {
+			checkOut.CheckOutTime = now
+		}
+		if err := tx.Create(checkOut).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&reservation).Update("status", ReservationStatusCheckedOut).Error; err != nil {
+			return err
+		}
+		err := tx.Model(&Room{}).Where("id = ?", reservation.RoomID).Update("status", RoomStatusCleaning).Error
+		if err != nil {
+			return err
+		}
+		_, err = AwardStayPoints(tx, &reservation, now)
+		return err
+	})
+}
+
+// AwardStayPoints credits the guest with points for the reservation's room
+// nights and delivered room service, boosted by their tier, then reviews
+// their tier. A reservation earns once; later calls return nothing.
+func AwardStayPoints(tx *gorm.DB, reservation *Reservation, now time.Time) ([]LoyaltyTransaction, error) {
+	var awarded int64
+	err := tx.Model(&LoyaltyTransaction{}).
+		Where("reservation_id = ? AND entry_type IN ?", reservation.ID, []string{LoyaltyEarnStay, LoyaltyEarnRoomService}).
+		Count(&awarded).Error
+	if err != nil || awarded > 0 {
+		return nil, err
+	}
+
+	var guest Guest
+	if err := tx.First(&guest, reservation.GuestID).Error; err != nil {
+		return nil, err
+	}
+	tiers, err := loyaltyTiers(tx)
+	if err != nil {
+		return nil, err
+	}
+	bonus := 0.0
+	for _, tier := range tiers {
+		if tier.Name == guest.LoyaltyTier {
+			bonus = tier.BonusPercent
+		}
+	}
+
+	var spend float64
+	err = tx.Model(&RoomServiceOrder{}).Where("reservation_id = ? AND status = ?", reservation.ID, OrderStatusDelivered).
+		Select("COALESCE(SUM(total), 0)").Scan(&spend).Error
+	if err != nil {
+		return nil, err
+	}
+
+	earnings := []struct {
+		entryType   string
+		points      float64
+		description string
+	}{
+		{LoyaltyEarnStay, float64(reservation.Nights * LoyaltyPointsPerNight),
+			fmt.Sprintf("%d nights, %s", reservation.Nights, reservation.BookingID)},
+		{LoyaltyEarnRoomService, math.Floor(spend) * LoyaltyPointsPerSpend,
+			fmt.Sprintf("Room service %.2f, %s", spend, reservation.BookingID)},
+	}
+	var entries []LoyaltyTransaction
+	for _, earning := range earnings {
+		points := int(math.Floor(earning.points * (1 + bonus/100)))
+		if points <= 0 {
+			continue
+		}
+		entry, err := postLoyalty(tx, &guest, LoyaltyTransaction{
+			ReservationID: &reservation.ID,
+			EntryType:     earning.entryType,
+			Points:        points,
+			Description:   earning.description,
+			PostedAt:      now,
+		})
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+
+	_, err = ReviewLoyaltyTier(tx, &guest, now)
+	return entries, err
+}
+
+// RedeemLoyaltyPoints spends points against a folio charge, posting a
+// credit of LoyaltyPointValue per point. No more can be redeemed than what
+// is left of the charge after earlier redemptions and any credit against
+// the order it was posted for; points beyond that are not taken. Charges
+// for cancelled room service orders cannot be redeemed against.
+func RedeemLoyaltyPoints(db *gorm.DB, guest *Guest, chargeID uint, points int, staffID *uint, now time.Time) (*LoyaltyTransaction, error) {
+	var entry *LoyaltyTransaction
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(guest, guest.ID).Error; err != nil {
+			return err
+		}
+		if points <= 0 || points > guest.LoyaltyPoints {
+			return fmt.Errorf("%w: %d requested, %d available", ErrInsufficientPoints, points, guest.LoyaltyPoints)
+		}
+
+		var charge FolioCharge
+		if err := tx.Preload("Reservation").First(&charge, chargeID).Error; err != nil {
+			return err
+		}
+		if charge.GuestID != guest.ID {
+			return gorm.ErrRecordNotFound
+		}
+		switch charge.Reservation.Status {
+		case ReservationStatusPending, ReservationStatusConfirmed, ReservationStatusCheckedIn:
+		default:
+			return fmt.Errorf("%w: %s", ErrFolioClosed, charge.Reservation.Status)
+		}
+
+		if charge.SourceType == SourceRoomServiceOrder {
+			var order RoomServiceOrder
+			if err := tx.First(&order, charge.SourceID).Error; err != nil {
+				return err
+			}
+			if order.Status == OrderStatusCancelled {
+				return fmt.Errorf("%w: order %s is cancelled", ErrNothingToRedeem, order.OrderID)
+			}
+		}
+
+		var credited, sourceCredited float64
+		err := tx.Model(&FolioCharge{}).Where("source_type = ? AND source_id = ?", SourceFolioCharge, charge.ID).
+			Select("COALESCE(SUM(amount), 0)").Scan(&credited).Error
+		if err != nil {
+			return err
+		}
+		if charge.SourceType != "" && charge.SourceID != 0 {
+			err = tx.Model(&FolioCharge{}).
+				Where("source_type = ? AND source_id = ? AND id <> ? AND amount < 0", charge.SourceType, charge.SourceID, charge.ID).
+				Select("COALESCE(SUM(amount), 0)").Scan(&sourceCredited).Error
+			if err != nil {
+				return err
+			}
+		}
+		remaining := roundMoney(charge.Amount + credited + sourceCredited)
+		if remaining <= 0 {
+			return ErrNothingToRedeem
+		}
+		if float64(points)*LoyaltyPointValue > remaining {
+			points = int(math.Ceil(roundMoney(remaining / LoyaltyPointValue)))
+		}
+		value := math.Min(roundMoney(float64(points)*LoyaltyPointValue), remaining)
+
+		credit := FolioCharge{
+			ReservationID: charge.ReservationID,
+			GuestID:       guest.ID,
+			ChargeType:    FolioChargeLoyalty,
+			Description:   fmt.Sprintf("%d loyalty points against %s", points, charge.Description),
+			Amount:        -value,
+			SourceType:    SourceFolioCharge,
+			SourceID:      charge.ID,
+			PostedAt:      now,
+		}
+		if err := tx.Create(&credit).Error; err != nil {
+			return err
+		}
+		entry, err = postLoyalty(tx, guest, LoyaltyTransaction{
+			ReservationID: &charge.ReservationID,
+			EntryType:     LoyaltyRedeem,
+			Points:        -points,
+			Description:   credit.Description,
+			FolioChargeID: &credit.ID,
+			StaffID:       staffID,
+			PostedAt:      now,
+		})
+		return err
+	})
+	return entry, err
+}
+
+// refundRedemptions reverses the loyalty credits posted against the folio
+// charges and gives the points back, for charges that are being credited
+// in full
+func refundRedemptions(tx *gorm.DB, chargeIDs []uint, reason string, now time.Time) error {
+	if len(chargeIDs) == 0 {
+		return nil
+	}
+	var redemptions []LoyaltyTransaction
+	err := tx.Where("entry_type = ? AND folio_charge_id IN (?)", LoyaltyRedeem,
+		tx.Model(&FolioCharge{}).Select("id").
+			Where("source_type = ? AND source_id IN ? AND amount < 0", SourceFolioCharge, chargeIDs)).
+		Order("id").Find(&redemptions).Error
+	if err != nil {
+		return err
+	}
+	for _, redemption := range redemptions {
+		var credit FolioCharge
+		if err := tx.First(&credit, *redemption.FolioChargeID).Error; err != nil {
+			return err
+		}
+		reversal := FolioCharge{
+			ReservationID: credit.ReservationID,
+			GuestID:       credit.GuestID,
+			ChargeType:    FolioChargeLoyalty,
+			Description:   credit.Description + " refunded: " + reason,
+			Amount:        -credit.Amount,
+			SourceType:    SourceFolioCharge,
+			SourceID:      credit.SourceID,
+			PostedAt:      now,
+		}
+		if err := tx.Create(&reversal).Error; err != nil {
+			return err
+		}
+		guest := Guest{ID: redemption.GuestID}
+		_, err := postLoyalty(tx, &guest, LoyaltyTransaction{
+			ReservationID: redemption.ReservationID,
+			EntryType:     LoyaltyRefund,
+			Points:        -redemption.Points,
+			Description:   reversal.Description,
+			FolioChargeID: &reversal.ID,
+			PostedAt:      now,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AdjustLoyaltyPoints adds or removes points by hand, e.g. as a goodwill
+// gesture. The balance cannot go below zero.
+func AdjustLoyaltyPoints(db *gorm.DB, guest *Guest, points int, reason string, staffID *uint, now time.Time) (*LoyaltyTransaction, error) {
+	var entry *LoyaltyTransaction
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.First(guest, guest.ID).Error; err != nil {
+			return err
+		}
+		if guest.LoyaltyPoints+points < 0 {
+			return fmt.Errorf("%w: %d available", ErrInsufficientPoints, guest.LoyaltyPoints)
+		}
+		var err error
+		entry, err = postLoyalty(tx, guest, LoyaltyTransaction{
+			EntryType:   LoyaltyAdjust,
+			Points:      points,
+			Description: reason,
+			StaffID:     staffID,
+			PostedAt:    now,
+		})
+		return err
+	})
+	return entry, err
+}
+
+// postLoyalty writes a ledger entry and moves the guest's balance with it
+func postLoyalty(tx *gorm.DB, guest *Guest, entry LoyaltyTransaction) (*LoyaltyTransaction, error) {
+	err := tx.Model(guest).UpdateColumn("loyalty_points", gorm.Expr("loyalty_points + ?", entry.Points)).Error
+	if err != nil {
+		return nil, err
+	}
+	err = tx.Model(&Guest{}).Where("id = ?", guest.ID).Select("loyalty_points").Scan(&guest.LoyaltyPoints).Error
+	if err != nil {
+		return nil, err
+	}
+	entry.GuestID = guest.ID
+	entry.Balance = guest.LoyaltyPoints
+	if err := tx.Create(&entry).Error; err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// GetLoyaltyStatus reports the guest's tier, balance and qualifying
+// activity in the window, and how far they are from the next tier
+func GetLoyaltyStatus(tx *gorm.DB, guest *Guest, now time.Time) (*LoyaltyStatus, error) {
+	tiers, err := loyaltyTiers(tx)
+	if err != nil {
+		return nil, err
+	}
+	status := &LoyaltyStatus{
+		Tier:        guest.LoyaltyTier,
+		Points:      guest.LoyaltyPoints,
+		PointsValue: roundMoney(float64(guest.LoyaltyPoints) * LoyaltyPointValue),
+		WindowStart: now.AddDate(0, -LoyaltyWindowMonths, 0),
+	}
+	if status.Tier == "" {
+		status.Tier = LoyaltyTierMember
+	}
+	if status.Stays, status.Spend, err = qualifyingActivity(tx, guest.ID, status.WindowStart); err != nil {
+		return nil, err
+	}
+
+	rank := 0
+	for _, tier := range tiers {
+		if tier.Name == status.Tier {
+			rank = tier.Rank
+		}
+	}
+	for _, tier := range tiers {
+		if tier.Rank > rank {
+			status.NextTier = tier.Name
+			if tier.MinStays > 0 {
+				status.StaysToNextTier = int(math.Max(0, float64(tier.MinStays-status.Stays)))
+			}
+			if tier.MinSpend > 0 {
+				status.SpendToNextTier = roundMoney(math.Max(0, tier.MinSpend-status.Spend))
+			}
+			break
+		}
+	}
+	return status, nil
+}
+
+// ReviewLoyaltyTier moves the guest to the highest tier whose stays or spend
+// threshold they meet within the window, up or down, and returns it
+func ReviewLoyaltyTier(tx *gorm.DB, guest *Guest, now time.Time) (string, error) {
+	tiers, err := loyaltyTiers(tx)
+	if err != nil {
+		return "", err
+	}
+	stays, spend, err := qualifyingActivity(tx, guest.ID, now.AddDate(0, -LoyaltyWindowMonths, 0))
+	if err != nil {
+		return "", err
+	}
+
+	tier := LoyaltyTierMember
+	for _, t := range tiers {
+		if (t.MinStays > 0 && stays >= t.MinStays) || (t.MinSpend > 0 && spend >= t.MinSpend) {
+			tier = t.Name
+		}
+	}
+	if tier == guest.LoyaltyTier {
+		return tier, nil
+	}
+	guest.LoyaltyTier = tier
+	return tier, tx.Model(guest).Update("loyalty_tier", tier).Error
+}
+
+// ReviewLoyaltyTiers re-reviews every guest above member, so tiers drop when
+// qualifying stays leave the window. It is run nightly.
+func ReviewLoyaltyTiers(tx *gorm.DB, now time.Time) ([]Guest, error) {
+	var guests []Guest
+	err := tx.Where("loyalty_tier <> ?", LoyaltyTierMember).Find(&guests).Error
+	if err != nil {
+		return nil, err
+	}
+	var changed []Guest
+	for i := range guests {
+		before := guests[i].LoyaltyTier
+		tier, err := ReviewLoyaltyTier(tx, &guests[i], now)
+		if err != nil {
+			return nil, err
+		}
+		if tier != before {
+			changed = append(changed, guests[i])
+		}
+	}
+	return changed, nil
+}
+
+// qualifyingActivity counts the guest's stays checked out since from and
+// their spend on those stays, room and delivered room service
+func qualifyingActivity(tx *gorm.DB, guestID uint, from time.Time) (int, float64, error) {
+	var reservations []Reservation
+	err := tx.Where("guest_id = ? AND status = ? AND check_out_date >= ?", guestID, ReservationStatusCheckedOut, from).
+		Find(&reservations).Error
+	if err != nil || len(reservations) == 0 {
+		return 0, 0, err
+	}
+	ids := make([]uint, len(reservations))
+	spend := 0.0
+	for i, reservation := range reservations {
+		ids[i] = reservation.ID
+		spend += reservation.TotalPrice
+	}
+	var roomService float64
+	err = tx.Model(&RoomServiceOrder{}).Where("reservation_id IN ? AND status = ?", ids, OrderStatusDelivered).
+		Select("COALESCE(SUM(total), 0)").Scan(&roomService).Error
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(reservations), roundMoney(spend + roomService), nil
+}
+
+// loyaltyTiers returns the active tiers from lowest to highest rank, or
+// DefaultLoyaltyTiers if none are configured
+func loyaltyTiers(tx *gorm.DB) ([]LoyaltyTier, error) {
+	var tiers []LoyaltyTier
+	if err := tx.Where("active = ?", true).Order("rank").Find(&tiers).Error; err != nil {
+		return nil, err
+	}
+	if len(tiers) == 0 {
+		return DefaultLoyaltyTiers, nil
+	}
+	return tiers, nil
+}
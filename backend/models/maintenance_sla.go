@@ -0,0 +1,357 @@
+package models
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Maintenance issue statuses
+const (
+	IssueStatusReported     = "reported"
+	IssueStatusAcknowledged = "acknowledged"
+	IssueStatusInProgress   = "in-progress"
+	IssueStatusResolved     = "resolved"
+)
+
+// SLA statuses reported on maintenance issues
+const (
+	SLAStatusOnTrack  = "on-track"
+	SLAStatusAtRisk   = "at-risk"
+	SLAStatusBreached = "breached"
+	SLAStatusMet      = "met"
+)
+
+// Escalation levels
+const (
+	EscalationAcknowledgeBreached = 1
+	EscalationResolveBreached     = 2
+)
+
+// slaAtRiskFraction is how much of the resolve window may pass before an
+// open issue is reported as at risk.
+const slaAtRiskFraction = 0.75
+
+// DefaultIssuePriority is the priority given to guest-reported issues, which
+// do not carry one of their own
+var DefaultIssuePriority = map[string]string{
+	"ac":        "high",
+	"plumbing":  "high",
+	"door-lock": "high",
+	"wifi":      "medium",
+	"lights":    "medium",
+	"noise":     "medium",
+	"tv":        "low",
+	"other":     "low",
+}
+
+// defaultMaintenanceSLAs applies when no MaintenanceSLA row matches, in
+// minutes to acknowledge and to resolve
+var defaultMaintenanceSLAs = map[string][2]int{
+	"high":   {15, 4 * 60},
+	"medium": {30, 24 * 60},
+	"low":    {60, 72 * 60},
+}
+
+var maintenanceIssueTransitions = map[string][]string{
+	IssueStatusReported:     {IssueStatusAcknowledged, IssueStatusInProgress, IssueStatusResolved},
+	IssueStatusAcknowledged: {IssueStatusInProgress, IssueStatusResolved},
+	IssueStatusInProgress:   {IssueStatusResolved},
+}
+
+// FindMaintenanceSLA returns the SLA for an issue type and priority,
+// preferring a type-specific row over a priority-wide one and falling back
+// to the built-in defaults.
+func FindMaintenanceSLA(tx *gorm.DB, issueType, priority string) (MaintenanceSLA, error) {
+	var slas []MaintenanceSLA
+	err := tx.Where("priority = ? AND issue_type IN ?", priority, []string{issueType, ""}).
+		Find(&slas).Error
+	if err != nil {
+		return MaintenanceSLA{}, err
+	}
+
+	var match *MaintenanceSLA
+	for i := range slas {
+		if slas[i].IssueType == issueType || match == nil {
+			match = &slas[i]
+		}
+	}
+	if match != nil {
+		return *match, nil
+	}
+
+	targets, ok := defaultMaintenanceSLAs[priority]
+	if !ok {
+		targets = defaultMaintenanceSLAs["low"]
+	}
+	return MaintenanceSLA{
+		IssueType:          issueType,
+		Priority:           priority,
+		AcknowledgeMinutes: targets[0],
+		ResolveMinutes:     targets[1],
+	}, nil
+}
+
+// ApplyMaintenanceSLA fills in a new issue's priority, if missing, and its
+// acknowledge and resolve deadlines. Call it before the issue is created.
+func ApplyMaintenanceSLA(tx *gorm.DB, issue *MaintenanceIssue) error {
+	if issue.Priority == "" {
+		issue.Priority = DefaultIssuePriority[issue.IssueType]
+		if issue.Priority == "" {
+			issue.Priority = "low"
+		}
+	}
+	if issue.ReportedAt.IsZero() {
+		issue.ReportedAt = time.Now()
+	}
+
+	sla, err := FindMaintenanceSLA(tx, issue.IssueType, issue.Priority)
+	if err != nil {
+		return err
+	}
+	ackDue := issue.ReportedAt.Add(time.Duration(sla.AcknowledgeMinutes) * time.Minute)
+	resolveDue := issue.ReportedAt.Add(time.Duration(sla.ResolveMinutes) * time.Minute)
+	issue.AcknowledgeDueAt = &ackDue
+	issue.ResolveDueAt = &resolveDue
+	return nil
+}
+
+// AdvanceMaintenanceIssue moves an issue to the given status. Any move out of
+// "reported" counts as acknowledgement, and resolving the issue puts a room it
+// took out of order back into service.
+func AdvanceMaintenanceIssue(tx *gorm.DB, issue *MaintenanceIssue, status string, now time.Time) error {
+	if !canTransition(maintenanceIssueTransitions, issue.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, issue.Status, status)
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if issue.AcknowledgedAt == nil {
+		updates["acknowledged_at"] = now
+		issue.AcknowledgedAt = &now
+	}
+	if status == IssueStatusResolved {
+		updates["resolved_at"] = now
+		issue.ResolvedAt = &now
+	}
+	issue.Status = status
+	if err := tx.Model(issue).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if status == IssueStatusResolved {
+		return RestoreRoomAfterMaintenance(tx, issue, now)
+	}
+	return nil
+}
+
+// SLAStatus reports where an issue stands against its resolve deadline
+func (i MaintenanceIssue) SLAStatus(now time.Time) string {
+	if i.ResolveDueAt == nil {
+		return ""
+	}
+	if i.ResolvedAt != nil {
+		if i.ResolvedAt.After(*i.ResolveDueAt) {
+			return SLAStatusBreached
+		}
+		return SLAStatusMet
+	}
+	if now.After(*i.ResolveDueAt) {
+		return SLAStatusBreached
+	}
+	if i.AcknowledgedAt == nil && i.AcknowledgeDueAt != nil && now.After(*i.AcknowledgeDueAt) {
+		return SLAStatusAtRisk
+	}
+	window := i.ResolveDueAt.Sub(i.ReportedAt)
+	if window > 0 && float64(now.Sub(i.ReportedAt)) >= float64(window)*slaAtRiskFraction {
+		return SLAStatusAtRisk
+	}
+	return SLAStatusOnTrack
+}
+
+// AcknowledgeBreached reports whether the issue was acknowledged after its
+// acknowledge deadline, or is still unacknowledged past it
+func (i MaintenanceIssue) AcknowledgeBreached(now time.Time) bool {
+	if i.AcknowledgeDueAt == nil {
+		return false
+	}
+	if i.AcknowledgedAt != nil {
+		return i.AcknowledgedAt.After(*i.AcknowledgeDueAt)
+	}
+	return now.After(*i.AcknowledgeDueAt)
+}
+
+// TimeToAcknowledge returns how long the issue waited for acknowledgement
+func (i MaintenanceIssue) TimeToAcknowledge() *time.Duration {
+	if i.AcknowledgedAt == nil {
+		return nil
+	}
+	d := i.AcknowledgedAt.Sub(i.ReportedAt)
+	return &d
+}
+
+// TimeToResolve returns how long the issue took to resolve
+func (i MaintenanceIssue) TimeToResolve() *time.Duration {
+	if i.ResolvedAt == nil {
+		return nil
+	}
+	d := i.ResolvedAt.Sub(i.ReportedAt)
+	return &d
+}
+
+// EscalateMaintenanceSLABreaches escalates every open issue that has missed
+// its acknowledge or resolve deadline to the on-duty manager. Each issue is
+// escalated at most once per level, so the job can run as often as needed.
+func EscalateMaintenanceSLABreaches(tx *gorm.DB, now time.Time) ([]MaintenanceEscalation, error) {
+	var issues []MaintenanceIssue
+	err := tx.Where("status <> ? AND ((acknowledged_at IS NULL AND acknowledge_due_at < ? AND escalation_level < ?) OR (resolve_due_at < ? AND escalation_level < ?))",
+		IssueStatusResolved, now, EscalationAcknowledgeBreached, now, EscalationResolveBreached).
+		Order("reported_at").Find(&issues).Error
+	if err != nil {
+		return nil, err
+	}
+	if len(issues) == 0 {
+		return nil, nil
+	}
+
+	manager, err := FindOnDutyManager(tx, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var escalations []MaintenanceEscalation
+	for _, issue := range issues {
+		escalation := MaintenanceEscalation{
+			MaintenanceIssueID: issue.ID,
+			EscalatedAt:        now,
+		}
+		if issue.ResolveDueAt != nil && now.After(*issue.ResolveDueAt) {
+			escalation.Level = EscalationResolveBreached
+			escalation.Reason = "resolve-breached"
+			escalation.DueAt = *issue.ResolveDueAt
+		} else {
+			escalation.Level = EscalationAcknowledgeBreached
+			escalation.Reason = "acknowledge-breached"
+			escalation.DueAt = *issue.AcknowledgeDueAt
+		}
+		if manager != nil {
+			escalation.EscalatedToID = &manager.ID
+		}
+
+		if err := tx.Create(&escalation).Error; err != nil {
+			return nil, err
+		}
+		err := tx.Model(&MaintenanceIssue{}).Where("id = ?", issue.ID).Updates(map[string]interface{}{
+			"escalation_level": escalation.Level,
+			"escalated_at":     now,
+			"escalated_to_id":  escalation.EscalatedToID,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+		escalations = append(escalations, escalation)
+	}
+	return escalations, nil
+}
+
+// FindOnDutyManager returns the manager escalations should go to, or nil if
+// there is none: the manager clocked in longest, or, when no manager is on
+// shift, the first active manager so breaches are never dropped.
+func FindOnDutyManager(tx *gorm.DB, now time.Time) (*Staff, error) {
+	var shift StaffShift
+	result := tx.Preload("Staff").
+		Where("role = ? AND status = ? AND clock_in_at <= ? AND staff_id IN (?)",
+			StaffRoleManager, ShiftStatusOnDuty, now,
+			tx.Model(&Staff{}).Select("id").Where("status = ?", StaffStatusActive)).
+		Order("clock_in_at").Limit(1).Find(&shift)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return &shift.Staff, nil
+	}
+
+	var manager Staff
+	result = tx.Where("role = ? AND status = ?", StaffRoleManager, StaffStatusActive).
+		Order("id").Limit(1).Find(&manager)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, nil
+	}
+	return &manager, nil
+}
+
+// MaintenanceSLAMetrics summarises acknowledge and resolve performance
+type MaintenanceSLAMetrics struct {
+	Priority              string  `json:"priority"`
+	Issues                int     `json:"issues"`
+	Acknowledged          int     `json:"acknowledged"`
+	Resolved              int     `json:"resolved"`
+	AvgAcknowledgeMinutes float64 `json:"avg_acknowledge_minutes"`
+	AvgResolveMinutes     float64 `json:"avg_resolve_minutes"`
+	AcknowledgeBreaches   int     `json:"acknowledge_breaches"`
+	ResolveBreaches       int     `json:"resolve_breaches"`
+	CompliancePercent     float64 `json:"compliance_percent"` // resolved issues that met the resolve SLA
+}
+
+// CalculateMaintenanceSLAMetrics reports time-to-acknowledge, time-to-resolve
+// and breaches per priority for issues reported between from and to. Issues
+// still open are measured against now.
+func CalculateMaintenanceSLAMetrics(tx *gorm.DB, from, to, now time.Time) ([]MaintenanceSLAMetrics, error) {
+	var issues []MaintenanceIssue
+	err := tx.Where("reported_at >= ? AND reported_at < ?", from, to).Find(&issues).Error
+	if err != nil {
+		return nil, err
+	}
+
+	type totals struct {
+		metrics            MaintenanceSLAMetrics
+		ackTotal, resTotal time.Duration
+		metSLA             int
+	}
+	byPriority := make(map[string]*totals)
+	var order []string
+	for _, issue := range issues {
+		t, ok := byPriority[issue.Priority]
+		if !ok {
+			t = &totals{metrics: MaintenanceSLAMetrics{Priority: issue.Priority}}
+			byPriority[issue.Priority] = t
+			order = append(order, issue.Priority)
+		}
+		t.metrics.Issues++
+		if ack := issue.TimeToAcknowledge(); ack != nil {
+			t.metrics.Acknowledged++
+			t.ackTotal += *ack
+		}
+		if issue.AcknowledgeBreached(now) {
+			t.metrics.AcknowledgeBreaches++
+		}
+		if res := issue.TimeToResolve(); res != nil {
+			t.metrics.Resolved++
+			t.resTotal += *res
+		}
+		switch issue.SLAStatus(now) {
+		case SLAStatusBreached:
+			t.metrics.ResolveBreaches++
+		case SLAStatusMet:
+			t.metSLA++
+		}
+	}
+
+	metrics := make([]MaintenanceSLAMetrics, 0, len(order))
+	for _, priority := range order {
+		t := byPriority[priority]
+		if t.metrics.Acknowledged > 0 {
+			t.metrics.AvgAcknowledgeMinutes = math.Round(t.ackTotal.Minutes()/float64(t.metrics.Acknowledged)*10) / 10
+		}
+		if t.metrics.Resolved > 0 {
+			t.metrics.AvgResolveMinutes = math.Round(t.resTotal.Minutes()/float64(t.metrics.Resolved)*10) / 10
+			t.metrics.CompliancePercent = math.Round(float64(t.metSLA)/float64(t.metrics.Resolved)*1000) / 10
+		}
+		metrics = append(metrics, t.metrics)
+	}
+	return metrics, nil
+}
@@ -17,16 +17,50 @@ type Guest struct {
 	IDType      string         `json:"id_type"` // Passport, Driver's License, etc.
 	IDNumber    string         `json:"id_number"`
 	JoinDate    time.Time      `json:"join_date"`
+	CorporateAccountID *uint   `gorm:"index" json:"corporate_account_id"` // company the guest travels for
+	LoyaltyTier   string       `gorm:"default:member" json:"loyalty_tier"` // member, silver, gold, platinum
+	LoyaltyPoints int          `json:"loyalty_points"` // balance of the loyalty ledger
 	CreatedAt   time.Time      `json:"created_at"`
 	UpdatedAt   time.Time      `json:"updated_at"`
 
 	// Relations
+	CorporateAccount *CorporateAccount `gorm:"foreignKey:CorporateAccountID" json:"corporate_account,omitempty"`
 	Reservations     []Reservation     `gorm:"foreignKey:GuestID" json:"reservations,omitempty"`
 	ServiceRequests  []ServiceRequest  `gorm:"foreignKey:GuestID" json:"service_requests,omitempty"`
 	Preferences      GuestPreferences  `gorm:"foreignKey:GuestID" json:"preferences,omitempty"`
 	AIInsights       GuestAIInsights   `gorm:"foreignKey:GuestID" json:"ai_insights,omitempty"`
 }
 
+// LoyaltyTransaction represents one entry in a guest's loyalty ledger.
+// Points are positive when earned and negative when redeemed.
+type LoyaltyTransaction struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	GuestID       uint      `gorm:"index" json:"guest_id"`
+	ReservationID *uint     `gorm:"index" json:"reservation_id"`
+	EntryType     string    `json:"entry_type"` // earn-stay, earn-room-service, redeem, refund, adjust
+	Points        int       `json:"points"`
+	Balance       int       `json:"balance"` // after this entry
+	Description   string    `json:"description"`
+	FolioChargeID *uint     `json:"folio_charge_id"` // credit posted for a redemption
+	StaffID       *uint     `json:"staff_id"`
+	PostedAt      time.Time `json:"posted_at"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// LoyaltyTier represents a tier of the loyalty programme, reached by stays
+// or by spend within the rolling qualification window
+type LoyaltyTier struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Name         string    `gorm:"uniqueIndex" json:"name"` // silver, gold, platinum
+	Rank         int       `json:"rank"` // higher is better
+	MinStays     int       `json:"min_stays"` // 0 if stays do not qualify
+	MinSpend     float64   `json:"min_spend"` // 0 if spend does not qualify
+	BonusPercent float64   `json:"bonus_percent"` // extra points earned
+	Active       bool      `json:"active"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
 // GuestPreferences stores guest preferences
 type GuestPreferences struct {
 	ID               uint            `gorm:"primaryKey" json:"id"`
@@ -53,6 +87,28 @@ type GuestAIInsights struct {
 	UpdatedAt           time.Time `json:"updated_at"`
 }
 
+// GuestLoginLink represents a single-use magic link emailed to a guest to
+// sign in to the guest portal
+type GuestLoginLink struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	GuestID   uint       `gorm:"index" json:"guest_id"`
+	TokenHash string     `gorm:"uniqueIndex" json:"-"` // SHA-256 of the token in the link
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// GuestSession represents a guest signed in to the guest portal
+type GuestSession struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	GuestID    uint       `gorm:"index" json:"guest_id"`
+	TokenHash  string     `gorm:"uniqueIndex" json:"-"` // SHA-256 of the session token
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	RevokedAt  *time.Time `json:"revoked_at"` // signed out
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
 // Room represents a hotel room
 type Room struct {
 	ID            uint      `gorm:"primaryKey" json:"id"`
@@ -67,6 +123,7 @@ type Room struct {
 
 	// Relations
 	Reservations []Reservation `gorm:"foreignKey:RoomID" json:"reservations,omitempty"`
+	Assets       []RoomAsset   `gorm:"foreignKey:RoomID" json:"assets,omitempty"`
 }
 
 // Reservation represents a booking
@@ -80,14 +137,356 @@ type Reservation struct {
 	Nights        int       `json:"nights"`
 	TotalPrice    float64   `json:"total_price"`
 	PaidAmount    float64   `json:"paid_amount"`
-	Status        string    `json:"status"` // pending, confirmed, checked-in, checked-out, cancelled
+	Status        string    `json:"status"` // pending, confirmed, checked-in, checked-out, cancelled, no-show
+	RatePlan      string    `json:"rate_plan"` // rate plan code, e.g. BAR; set when the stay is priced
+	GroupBookingID *uint    `gorm:"index" json:"group_booking_id"` // block the room was picked up from
+	CancellationPolicyID *uint `json:"cancellation_policy_id"` // policy applied on cancellation or no-show
+	CancelledAt   *time.Time `json:"cancelled_at"`
+	CancellationReason string `json:"cancellation_reason"`
+	RefundAmount  float64   `json:"refund_amount"` // owed back to the guest after penalties
+	HoldExpiresAt *time.Time `gorm:"index" json:"hold_expires_at"` // pending hold is released after this
+	Overbooked    bool      `json:"overbooked"` // sold against the overbooking allowance, shares its room until one frees up
+	PromotionCode string    `json:"promotion_code"` // promo code given at booking, if any
+	DiscountAmount float64  `json:"discount_amount"` // total of Discounts, already taken off TotalPrice
+	Channel       string    `gorm:"index" json:"channel"` // sales channel code; empty for direct bookings
+	BookingTokenHash string `json:"-"` // lets a public website booking be confirmed by whoever made it
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Relations
 	Guest         Guest              `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
 	Room          Room               `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	GroupBooking  *GroupBooking      `gorm:"foreignKey:GroupBookingID" json:"group_booking,omitempty"`
 	ServiceRequests []ServiceRequest `gorm:"foreignKey:ReservationID" json:"service_requests,omitempty"`
+	FolioCharges  []FolioCharge      `gorm:"foreignKey:ReservationID" json:"folio_charges,omitempty"`
+	Occupants     []ReservationOccupant `gorm:"foreignKey:ReservationID" json:"occupants,omitempty"`
+	Changes       []ReservationChange   `gorm:"foreignKey:ReservationID" json:"changes,omitempty"`
+	Discounts     []ReservationDiscount `gorm:"foreignKey:ReservationID" json:"discounts,omitempty"`
+	NightRates    []ReservationNight    `gorm:"foreignKey:ReservationID" json:"night_rates,omitempty"`
+}
+
+// ReservationDiscount represents one discount taken off a reservation's
+// price, from a promotion or a corporate agreement
+type ReservationDiscount struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	ReservationID      uint      `gorm:"index" json:"reservation_id"`
+	PromotionID        *uint     `gorm:"index" json:"promotion_id"`
+	CorporateAccountID *uint     `gorm:"index" json:"corporate_account_id"`
+	Code               string    `json:"code"` // promo or corporate account code
+	Description        string    `json:"description"`
+	DiscountType       string    `json:"discount_type"` // percent, fixed, per-night, free-nights
+	Amount             float64   `json:"amount"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// ReservationNight represents the rate a night of a reservation was booked
+// at, before discounts; a changed stay keeps it for the nights it keeps
+type ReservationNight struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ReservationID uint      `gorm:"index" json:"reservation_id"`
+	Date          time.Time `json:"date"`
+	Rate          float64   `json:"rate"` // including breakfast
+	Breakfast     float64   `json:"breakfast"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ReservationChange represents one modification of a reservation's dates or
+// room, with the stay and price before and after
+type ReservationChange struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	ReservationID   uint      `gorm:"index" json:"reservation_id"`
+	ChangeType      string    `json:"change_type"` // date-change, room-change, date-and-room-change, early-departure, price-change
+	OldRoomID       uint      `json:"old_room_id"`
+	NewRoomID       uint      `json:"new_room_id"`
+	OldCheckInDate  time.Time `json:"old_check_in_date"`
+	NewCheckInDate  time.Time `json:"new_check_in_date"`
+	OldCheckOutDate time.Time `json:"old_check_out_date"`
+	NewCheckOutDate time.Time `json:"new_check_out_date"`
+	OldNights       int       `json:"old_nights"`
+	NewNights       int       `json:"new_nights"`
+	OldTotalPrice   float64   `json:"old_total_price"`
+	NewTotalPrice   float64   `json:"new_total_price"`
+	PriceDifference float64   `json:"price_difference"` // posted to the folio
+	ChangedByID     *uint     `json:"changed_by_id"` // Staff who made the change
+	Reason          string    `json:"reason"`
+	ChangedAt       time.Time `json:"changed_at"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Relations
+	ChangedBy *Staff `gorm:"foreignKey:ChangedByID" json:"changed_by,omitempty"`
+}
+
+// ReservationOccupant is a person staying in the reserved room. Adults must
+// have their ID recorded and verified before the reservation can check in.
+type ReservationOccupant struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ReservationID uint       `gorm:"index" json:"reservation_id"`
+	GuestID       *uint      `gorm:"index" json:"guest_id"` // set when the occupant is a known guest
+	IsPrimary     bool       `json:"is_primary"` // the booking guest
+	Name          string     `json:"name"`
+	AgeCategory   string     `json:"age_category"` // adult, child
+	Nationality   string     `json:"nationality"`
+	IDType        string     `json:"id_type"` // Passport, Driver's License, National ID
+	IDNumber      string     `json:"id_number"`
+	VerifiedAt    *time.Time `json:"verified_at"`
+	VerifiedByID  *uint      `json:"verified_by_id"` // Staff who checked the ID
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	Guest      *Guest `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	VerifiedBy *Staff `gorm:"foreignKey:VerifiedByID" json:"verified_by,omitempty"`
+}
+
+// RatePlan represents a way of selling rooms, such as best available rate,
+// corporate, non-refundable or breakfast included, priced relative to the
+// room's nightly rate
+type RatePlan struct {
+	ID                     uint      `gorm:"primaryKey" json:"id"`
+	Code                   string    `gorm:"uniqueIndex" json:"code"` // e.g. BAR, CORP, NRF, BB
+	Name                   string    `json:"name"`
+	Description            string    `json:"description"`
+	AdjustmentPercent      float64   `json:"adjustment_percent"` // on the base rate, e.g. -10
+	BreakfastIncluded      bool      `json:"breakfast_included"`
+	BreakfastPricePerGuest float64   `json:"breakfast_price_per_guest"` // per guest per night
+	NonRefundable          bool      `json:"non_refundable"` // pair with a cancellation policy for the code
+	MinNights              int       `json:"min_nights"`
+	OccupancyPricing       bool      `json:"occupancy_pricing"` // occupancy rules apply
+	Active                 bool      `json:"active"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+// SalesChannel represents an online travel agency or other channel that
+// sells rooms, receives availability and rates, and sends in bookings
+type SalesChannel struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	Code          string     `gorm:"uniqueIndex" json:"code"` // e.g. booking, expedia, mock
+	Name          string     `json:"name"`
+	HotelCode     string     `json:"hotel_code"` // the hotel's ID at the channel
+	RatePlans     datatypes.JSONSlice `gorm:"type:jsonb" json:"rate_plans"` // plans published; empty for BAR
+	Currency      string     `gorm:"default:USD" json:"currency"`
+	ARIFormat     string     `gorm:"default:json" json:"ari_format"` // json, ota-xml
+	ARIEndpoint   string     `json:"ari_endpoint"` // ARI is pushed here; empty if the channel pulls
+	TokenHash     string     `json:"-"` // SHA-256 of the token the channel sends bookings with
+	LastARIPushAt *time.Time `json:"last_ari_push_at"`
+	Active        bool       `json:"active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+// ChannelBooking represents a booking received from a sales channel, keyed
+// on the channel's confirmation number so repeated messages are applied once
+type ChannelBooking struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	ChannelID          uint      `gorm:"uniqueIndex:idx_channel_confirmation" json:"channel_id"`
+	ConfirmationNumber string    `gorm:"uniqueIndex:idx_channel_confirmation" json:"confirmation_number"`
+	ReservationID      uint      `gorm:"index" json:"reservation_id"`
+	GuestID            uint      `json:"guest_id"`
+	Status             string    `json:"status"` // booked, modified, cancelled
+	Payload            string    `gorm:"type:text" json:"payload"` // last message received, as sent
+	ImportedAt         time.Time `json:"imported_at"`
+	LastMessageAt      time.Time `json:"last_message_at"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Relations
+	Channel     SalesChannel `gorm:"foreignKey:ChannelID" json:"channel,omitempty"`
+	Reservation Reservation  `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+}
+
+// Promotion represents a promo code taking a discount off eligible stays
+// booked within its validity window
+type Promotion struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	Code               string     `gorm:"uniqueIndex" json:"code"` // stored upper-case
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	DiscountType       string     `json:"discount_type"` // percent, fixed, per-night, free-nights
+	DiscountValue      float64    `json:"discount_value"` // percent, amount or number of nights
+	BookFrom           *time.Time `json:"book_from"` // booking window, open if nil
+	BookUntil          *time.Time `json:"book_until"`
+	StayFrom           *time.Time `gorm:"type:date" json:"stay_from"` // every night must fall in the stay window
+	StayUntil          *time.Time `gorm:"type:date" json:"stay_until"` // last night, inclusive
+	MinNights          int        `json:"min_nights"`
+	RoomTypes          datatypes.JSONSlice `gorm:"type:jsonb" json:"room_types"` // empty for every type
+	UsageLimit         int        `json:"usage_limit"` // 0 for unlimited
+	UsageCount         int        `json:"usage_count"`
+	CorporateAccountID *uint      `gorm:"index" json:"corporate_account_id"` // only for the account's guests
+	Combinable         bool       `json:"combinable"` // may stack with a corporate discount
+	Active             bool       `json:"active"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// CorporateAccount represents a company with a negotiated discount for the
+// guests who travel for it
+type CorporateAccount struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	Code            string     `gorm:"uniqueIndex" json:"code"`
+	Name            string     `json:"name"`
+	ContactName     string     `json:"contact_name"`
+	ContactEmail    string     `json:"contact_email"`
+	RatePlan        string     `json:"rate_plan"` // plan its guests book by default, e.g. CORP
+	DiscountPercent float64    `json:"discount_percent"` // off the room total
+	ContractStart   *time.Time `json:"contract_start"`
+	ContractEnd     *time.Time `json:"contract_end"`
+	Active          bool       `json:"active"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+
+	// Relations
+	Guests []Guest `gorm:"foreignKey:CorporateAccountID" json:"guests,omitempty"`
+}
+
+// RateOverride represents a fixed nightly base rate for a room type over a
+// date range, such as a festival or low-season rate
+type RateOverride struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	RatePlanID  *uint     `gorm:"index" json:"rate_plan_id"` // nil for every plan
+	RoomType    string    `gorm:"index" json:"room_type"`
+	StartDate   time.Time `gorm:"type:date" json:"start_date"`
+	EndDate     time.Time `gorm:"type:date" json:"end_date"` // last night, inclusive
+	NightlyRate float64   `json:"nightly_rate"`
+	Reason      string    `json:"reason"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// OccupancyRateRule represents a price rise once forecast occupancy for a
+// night reaches a threshold
+type OccupancyRateRule struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	RatePlanID        *uint     `gorm:"index" json:"rate_plan_id"` // nil for every plan
+	MinOccupancy      float64   `json:"min_occupancy"` // percent of rooms booked
+	AdjustmentPercent float64   `json:"adjustment_percent"` // e.g. 15 for +15%
+	Active            bool      `json:"active"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// OverbookingAllowance represents how many rooms of a type may be sold
+// beyond physical inventory for one night
+type OverbookingAllowance struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RoomType  string    `gorm:"uniqueIndex:idx_overbooking_night" json:"room_type"`
+	Date      time.Time `gorm:"type:date;uniqueIndex:idx_overbooking_night" json:"date"` // the night of this date
+	Rooms     int       `json:"rooms"`
+	Notes     string    `json:"notes"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// WaitlistEntry represents a guest waiting for a room when none of the
+// types they would accept was free for their dates
+type WaitlistEntry struct {
+	ID             uint                `gorm:"primaryKey" json:"id"`
+	GuestID        uint                `gorm:"index" json:"guest_id"`
+	CheckInDate    time.Time           `json:"check_in_date"`
+	CheckOutDate   time.Time           `json:"check_out_date"`
+	RoomTypes      datatypes.JSONSlice `gorm:"type:jsonb" json:"room_types"` // acceptable types, most preferred first
+	Guests         int                 `json:"guests"` // party size the room must sleep
+	Status         string              `gorm:"index" json:"status"` // waiting, offered, accepted, declined, expired, cancelled
+	ReservationID  *uint               `json:"reservation_id"` // pending reservation held for the offer
+	OfferedAt      *time.Time          `json:"offered_at"`
+	OfferExpiresAt *time.Time          `json:"offer_expires_at"`
+	RespondedAt    *time.Time          `json:"responded_at"`
+	Notes          string              `json:"notes"`
+	CreatedAt      time.Time           `json:"created_at"`
+	UpdatedAt      time.Time           `json:"updated_at"`
+
+	// Relations
+	Guest       Guest        `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	Reservation *Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+}
+
+// CancellationPolicy represents the penalty for cancelling or not turning
+// up, for a rate plan and/or room type. Empty RatePlan or RoomType match any.
+type CancellationPolicy struct {
+	ID                    uint      `gorm:"primaryKey" json:"id"`
+	Name                  string    `json:"name"`
+	RatePlan              string    `gorm:"index" json:"rate_plan"`
+	RoomType              string    `gorm:"index" json:"room_type"`
+	NonRefundable         bool      `json:"non_refundable"` // penalty applies from booking
+	FreeCancellationHours int       `json:"free_cancellation_hours"` // free until this many hours before check-in
+	PenaltyType           string    `json:"penalty_type"` // none, first-night, percent, full-stay
+	PenaltyPercent        float64   `json:"penalty_percent"` // of the total price, for percent
+	NoShowPenaltyType     string    `json:"no_show_penalty_type"` // none, first-night, percent, full-stay
+	NoShowPenaltyPercent  float64   `json:"no_show_penalty_percent"`
+	Active                bool      `json:"active"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// FolioCharge represents a charge posted to a reservation's folio
+type FolioCharge struct {
+	ID            uint      `gorm:"primaryKey" json:"id"`
+	ReservationID uint      `gorm:"index" json:"reservation_id"`
+	GuestID       uint      `gorm:"index" json:"guest_id"`
+	ChargeType    string    `json:"charge_type"` // room, room-service, adjustment, cancellation, no-show, loyalty
+	Description   string    `json:"description"`
+	Amount        float64   `json:"amount"`
+	SourceType    string    `gorm:"index:idx_folio_source" json:"source_type"` // e.g. room_service_order
+	SourceID      uint      `gorm:"index:idx_folio_source" json:"source_id"`
+	PostedAt      time.Time `json:"posted_at"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+
+	// Relations
+	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+}
+
+// GroupBooking represents a block of rooms held for a wedding, conference or
+// tour. Guests on the rooming list become child reservations.
+type GroupBooking struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	Code           string     `gorm:"uniqueIndex" json:"code"`
+	Name           string     `json:"name"`
+	GroupType      string     `json:"group_type"` // wedding, conference, tour, other
+	ContactGuestID *uint      `json:"contact_guest_id"` // organiser, billed for the master folio
+	ContactName    string     `json:"contact_name"`
+	ContactEmail   string     `json:"contact_email"`
+	CheckInDate    time.Time  `json:"check_in_date"`
+	CheckOutDate   time.Time  `json:"check_out_date"`
+	ReleaseDate    time.Time  `gorm:"index" json:"release_date"` // unclaimed rooms go back on sale after this
+	MasterPaysRoom bool       `json:"master_pays_room"` // room charges of child reservations go to the master folio
+	Status         string     `gorm:"index" json:"status"` // active, released, cancelled
+	ReleasedAt     *time.Time `json:"released_at"`
+	Notes          string     `gorm:"type:text" json:"notes"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+
+	// Relations
+	ContactGuest  *Guest             `gorm:"foreignKey:ContactGuestID" json:"contact_guest,omitempty"`
+	Allotments    []GroupAllotment   `gorm:"foreignKey:GroupBookingID" json:"allotments,omitempty"`
+	Reservations  []Reservation      `gorm:"foreignKey:GroupBookingID" json:"reservations,omitempty"`
+	MasterCharges []GroupFolioCharge `gorm:"foreignKey:GroupBookingID" json:"master_charges,omitempty"`
+}
+
+// GroupAllotment is the number of rooms of one type held for a group
+type GroupAllotment struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	GroupBookingID uint      `gorm:"uniqueIndex:idx_group_allotment" json:"group_booking_id"`
+	RoomType       string    `gorm:"uniqueIndex:idx_group_allotment" json:"room_type"`
+	Rooms          int       `json:"rooms"`
+	RatePerNight   float64   `json:"rate_per_night"` // negotiated group rate
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// GroupFolioCharge is a charge posted to a group's master folio
+type GroupFolioCharge struct {
+	ID             uint      `gorm:"primaryKey" json:"id"`
+	GroupBookingID uint      `gorm:"index" json:"group_booking_id"`
+	ReservationID  *uint     `gorm:"index" json:"reservation_id"` // child reservation the charge came from, if any
+	ChargeType     string    `json:"charge_type"` // room, event, food-beverage, adjustment
+	Description    string    `json:"description"`
+	Amount         float64   `json:"amount"`
+	SourceType     string    `gorm:"index:idx_group_folio_source" json:"source_type"`
+	SourceID       uint      `gorm:"index:idx_group_folio_source" json:"source_id"`
+	PostedAt       time.Time `json:"posted_at"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
 }
 
 // ServiceRequest represents a guest service request
@@ -100,15 +499,17 @@ type ServiceRequest struct {
 	Priority      string    `json:"priority"` // low, medium, high
 	Description   string    `gorm:"type:text" json:"description"`
 	Notes         string    `gorm:"type:text" json:"notes"`
-	AssignedTo    string    `json:"assigned_to"`
+	AssignedTo    string    `json:"assigned_to"` // legacy free text, superseded by AssignedStaffID
+	AssignedStaffID *uint   `gorm:"index" json:"assigned_staff_id"`
 	RequestedAt   time.Time `json:"requested_at"`
 	CompletedAt   *time.Time `json:"completed_at"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Relations
-	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
-	Guest       Guest       `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	Reservation   Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+	Guest         Guest       `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	AssignedStaff *Staff      `gorm:"foreignKey:AssignedStaffID" json:"assigned_staff,omitempty"`
 }
 
 // RoomServiceOrder represents a room service order
@@ -117,20 +518,89 @@ type RoomServiceOrder struct {
 	OrderID       string    `gorm:"uniqueIndex" json:"order_id"`
 	ReservationID uint      `json:"reservation_id"`
 	GuestID       uint      `json:"guest_id"`
-	Items         datatypes.JSONSlice `gorm:"type:jsonb" json:"items"`
+	Items         datatypes.JSONSlice `gorm:"type:jsonb" json:"items"` // legacy, superseded by Lines
 	Subtotal      float64   `json:"subtotal"`
 	DeliveryFee   float64   `json:"delivery_fee"`
+	ServiceCharge float64   `json:"service_charge"`
+	VAT           float64   `json:"vat"`
 	Total         float64   `json:"total"`
-	Status        string    `json:"status"` // pending, preparing, delivered, cancelled
+	Status        string    `json:"status"` // pending, accepted, cooking, ready, out-for-delivery, delivered, cancelled
 	SpecialNotes  string    `gorm:"type:text" json:"special_notes"`
 	OrderedAt     time.Time `json:"ordered_at"`
+	AcceptedAt    *time.Time `json:"accepted_at"`
+	ReadyAt       *time.Time `json:"ready_at"`
+	DispatchedAt  *time.Time `json:"dispatched_at"`
+	EstimatedDeliveryAt *time.Time `json:"estimated_delivery_at"`
 	DeliveredAt   *time.Time `json:"delivered_at"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Relations
-	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
-	Guest       Guest       `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	Reservation Reservation            `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+	Guest       Guest                  `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	Lines       []RoomServiceOrderLine `gorm:"foreignKey:RoomServiceOrderID" json:"lines,omitempty"`
+	Tickets     []KitchenTicket        `gorm:"foreignKey:RoomServiceOrderID" json:"tickets,omitempty"`
+}
+
+// RoomServiceOrderLine is a single menu item on a room service order.
+// Name and UnitPrice are snapshotted from MenuItem when the order is placed.
+type RoomServiceOrderLine struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	RoomServiceOrderID uint      `gorm:"index" json:"room_service_order_id"`
+	MenuItemID         uint      `gorm:"index" json:"menu_item_id"`
+	KitchenTicketID    *uint     `gorm:"index" json:"kitchen_ticket_id"`
+	Name               string    `json:"name"`
+	Category           string    `json:"category"`
+	UnitPrice          float64   `json:"unit_price"`
+	Quantity           int       `json:"quantity"`
+	LineTotal          float64   `json:"line_total"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Relations
+	MenuItem MenuItem `gorm:"foreignKey:MenuItemID" json:"menu_item,omitempty"`
+}
+
+// KitchenStation represents a kitchen work station, e.g. grill, cold, bar
+type KitchenStation struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	Name               string    `gorm:"uniqueIndex" json:"name"`
+	DefaultPrepMinutes int       `json:"default_prep_minutes"` // used until enough history exists
+	Capacity           int       `json:"capacity"`             // tickets prepared in parallel
+	Active             bool      `json:"active"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Relations
+	Routes []KitchenStationRoute `gorm:"foreignKey:StationID" json:"routes,omitempty"`
+}
+
+// KitchenStationRoute routes a MenuItem category to a kitchen station
+type KitchenStationRoute struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	StationID uint      `gorm:"index" json:"station_id"`
+	Category  string    `gorm:"uniqueIndex" json:"category"` // matches MenuItem.Category
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// KitchenTicket is the part of a room service order prepared at one station
+type KitchenTicket struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	RoomServiceOrderID uint       `gorm:"index" json:"room_service_order_id"`
+	StationID          uint       `gorm:"index" json:"station_id"`
+	Status             string     `gorm:"index" json:"status"` // pending, accepted, cooking, ready, cancelled
+	EstimatedPrepMins  int        `json:"estimated_prep_mins"`
+	QueuedAt           time.Time  `json:"queued_at"`
+	AcceptedAt         *time.Time `json:"accepted_at"`
+	CookingAt          *time.Time `json:"cooking_at"`
+	ReadyAt            *time.Time `json:"ready_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relations
+	Station KitchenStation         `gorm:"foreignKey:StationID" json:"station,omitempty"`
+	Lines   []RoomServiceOrderLine `gorm:"foreignKey:KitchenTicketID" json:"lines,omitempty"`
 }
 
 // HousekeepingRequest represents a housekeeping service request
@@ -142,15 +612,120 @@ type HousekeepingRequest struct {
 	Description   string    `gorm:"type:text" json:"description"`
 	ScheduleTime  string    `json:"schedule_time"` // morning, afternoon, immediate
 	Status        string    `json:"status"` // pending, in-progress, completed
-	AssignedTo    string    `json:"assigned_to"`
+	AssignedTo    string    `json:"assigned_to"` // legacy free text, superseded by AssignedStaffID
+	AssignedStaffID *uint   `gorm:"index" json:"assigned_staff_id"`
+	TaskID        *uint     `gorm:"index" json:"task_id"` // HousekeepingTask scheduled for this request
 	RequestedAt   time.Time `json:"requested_at"`
 	CompletedAt   *time.Time `json:"completed_at"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Relations
-	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
-	Guest       Guest       `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	Reservation   Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+	Guest         Guest       `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	AssignedStaff *Staff      `gorm:"foreignKey:AssignedStaffID" json:"assigned_staff,omitempty"`
+}
+
+// HousekeepingTask is a timed unit of housekeeping work for one room
+type HousekeepingTask struct {
+	ID                    uint       `gorm:"primaryKey" json:"id"`
+	RoomID                uint       `gorm:"index" json:"room_id"`
+	ReservationID         *uint      `gorm:"index" json:"reservation_id"`
+	HousekeepingRequestID *uint      `gorm:"index" json:"housekeeping_request_id"`
+	TaskType              string     `json:"task_type"` // checkout, stay-over, guest-request, re-clean
+	Status                string     `gorm:"index" json:"status"` // pending, assigned, in-progress, cleaned, inspected, failed, cancelled
+	TaskDate              time.Time  `gorm:"index" json:"task_date"` // midnight of the day the task belongs to
+	WindowStart           time.Time  `json:"window_start"`
+	WindowEnd             time.Time  `json:"window_end"`
+	Floor                 int        `json:"floor"`
+	EstimatedMinutes      int        `json:"estimated_minutes"`
+	AssignedStaffID       *uint      `gorm:"index" json:"assigned_staff_id"`
+	StartedAt             *time.Time `json:"started_at"`
+	CleanedAt             *time.Time `json:"cleaned_at"`
+	InspectedAt           *time.Time `json:"inspected_at"`
+	InspectedByID         *uint      `json:"inspected_by_id"`
+	Notes                 string     `gorm:"type:text" json:"notes"`
+	CreatedAt             time.Time  `json:"created_at"`
+	UpdatedAt             time.Time  `json:"updated_at"`
+
+	// Relations
+	Room          Room   `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	AssignedStaff *Staff `gorm:"foreignKey:AssignedStaffID" json:"assigned_staff,omitempty"`
+}
+
+// InspectionChecklist is the list of points a supervisor checks before a
+// room of the given type is released after cleaning
+type InspectionChecklist struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	RoomType  string    `gorm:"uniqueIndex" json:"room_type"` // matches Room.RoomType
+	Name      string    `json:"name"`
+	PassScore float64   `json:"pass_score"` // minimum score (0-100) to pass
+	Active    bool      `json:"active"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Items []InspectionChecklistItem `gorm:"foreignKey:ChecklistID" json:"items,omitempty"`
+}
+
+// InspectionChecklistItem is a single point on an inspection checklist
+type InspectionChecklistItem struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ChecklistID uint      `gorm:"index" json:"checklist_id"`
+	Label       string    `json:"label"`
+	Area        string    `json:"area"` // bathroom, bed, floor, minibar, etc.
+	Weight      int       `json:"weight"`
+	Critical    bool      `json:"critical"` // failing this item fails the inspection
+	SortOrder   int       `json:"sort_order"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// RoomInspection records a supervisor's inspection of a cleaned room
+type RoomInspection struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	RoomID             uint      `gorm:"index" json:"room_id"`
+	HousekeepingTaskID uint      `gorm:"index" json:"housekeeping_task_id"`
+	ChecklistID        uint      `json:"checklist_id"`
+	InspectorID        uint      `gorm:"index" json:"inspector_id"`
+	HousekeeperID      *uint     `gorm:"index" json:"housekeeper_id"` // who cleaned the room
+	Result             string    `gorm:"index" json:"result"` // passed, failed
+	Score              float64   `json:"score"` // 0-100
+	Notes              string    `gorm:"type:text" json:"notes"`
+	RequeuedRequestID  *uint     `json:"requeued_request_id"` // re-clean request raised on failure
+	InspectedAt        time.Time `gorm:"index" json:"inspected_at"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+
+	// Relations
+	Room    Room                   `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Results []RoomInspectionResult `gorm:"foreignKey:InspectionID" json:"results,omitempty"`
+	Photos  []RoomInspectionPhoto  `gorm:"foreignKey:InspectionID" json:"photos,omitempty"`
+}
+
+// RoomInspectionResult is the pass/fail outcome of one checklist item
+type RoomInspectionResult struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	InspectionID    uint      `gorm:"index" json:"inspection_id"`
+	ChecklistItemID uint      `json:"checklist_item_id"`
+	Label           string    `json:"label"`
+	Weight          int       `json:"weight"`
+	Critical        bool      `json:"critical"`
+	Passed          bool      `json:"passed"`
+	Notes           string    `gorm:"type:text" json:"notes"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// RoomInspectionPhoto is a photo taken during an inspection
+type RoomInspectionPhoto struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	InspectionID    uint      `gorm:"index" json:"inspection_id"`
+	ChecklistItemID *uint     `json:"checklist_item_id"`
+	URL             string    `json:"url"`
+	Caption         string    `json:"caption"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
 }
 
 // MaintenanceIssue represents a maintenance issue report
@@ -158,19 +733,144 @@ type MaintenanceIssue struct {
 	ID            uint      `gorm:"primaryKey" json:"id"`
 	ReservationID uint      `json:"reservation_id"`
 	GuestID       uint      `json:"guest_id"`
+	RoomID        uint      `gorm:"index" json:"room_id"`
+	AssetID       *uint     `gorm:"index" json:"asset_id"` // RoomAsset that broke down, if known
 	IssueType     string    `json:"issue_type"` // ac, tv, wifi, plumbing, lights, door-lock, noise, other
 	Description   string    `gorm:"type:text" json:"description"`
-	Status        string    `json:"status"` // reported, in-progress, resolved
+	Status        string    `json:"status"` // reported, acknowledged, in-progress, resolved
 	Priority      string    `json:"priority"` // low, medium, high
-	AssignedTo    string    `json:"assigned_to"`
+	AssignedTo    string    `json:"assigned_to"` // legacy free text, superseded by AssignedStaffID
+	AssignedStaffID *uint   `gorm:"index" json:"assigned_staff_id"`
 	ReportedAt    time.Time `json:"reported_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at"`
 	ResolvedAt    *time.Time `json:"resolved_at"`
+	AcknowledgeDueAt *time.Time `gorm:"index" json:"acknowledge_due_at"`
+	ResolveDueAt  *time.Time `gorm:"index" json:"resolve_due_at"`
+	EscalationLevel int     `json:"escalation_level"` // 0 none, 1 acknowledge breached, 2 resolve breached
+	EscalatedAt   *time.Time `json:"escalated_at"`
+	EscalatedToID *uint     `json:"escalated_to_id"` // Staff (manager) the issue was escalated to
+	RoomOutOfOrder bool     `json:"room_out_of_order"` // room was put into maintenance for this issue
+	PreviousRoomStatus string `json:"previous_room_status"` // room status to restore on resolution
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 
 	// Relations
 	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
 	Guest       Guest       `gorm:"foreignKey:GuestID" json:"guest,omitempty"`
+	Room        Room        `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Asset       *RoomAsset  `gorm:"foreignKey:AssetID" json:"asset,omitempty"`
+	AssignedStaff *Staff    `gorm:"foreignKey:AssignedStaffID" json:"assigned_staff,omitempty"`
+	Escalations []MaintenanceEscalation `gorm:"foreignKey:MaintenanceIssueID" json:"escalations,omitempty"`
+	RoomMoves   []RoomMoveProposal      `gorm:"foreignKey:MaintenanceIssueID" json:"room_moves,omitempty"`
+}
+
+// RoomMoveProposal suggests moving a reservation out of a room that has been
+// taken out of order to an equivalent available room
+type RoomMoveProposal struct {
+	ID                 uint       `gorm:"primaryKey" json:"id"`
+	MaintenanceIssueID uint       `gorm:"index" json:"maintenance_issue_id"`
+	ReservationID      uint       `gorm:"index" json:"reservation_id"`
+	FromRoomID         uint       `json:"from_room_id"`
+	ToRoomID           *uint      `json:"to_room_id"` // nil when no equivalent room is free
+	Status             string     `gorm:"index" json:"status"` // proposed, accepted, rejected, no-alternative, withdrawn
+	DecidedAt          *time.Time `json:"decided_at"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+
+	// Relations
+	Reservation Reservation `gorm:"foreignKey:ReservationID" json:"reservation,omitempty"`
+	FromRoom    Room        `gorm:"foreignKey:FromRoomID" json:"from_room,omitempty"`
+	ToRoom      *Room       `gorm:"foreignKey:ToRoomID" json:"to_room,omitempty"`
+}
+
+// MaintenanceSLA sets acknowledge and resolve targets for maintenance issues.
+// An empty IssueType applies to every issue type of that priority.
+type MaintenanceSLA struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	IssueType          string    `gorm:"uniqueIndex:idx_maintenance_sla" json:"issue_type"`
+	Priority           string    `gorm:"uniqueIndex:idx_maintenance_sla" json:"priority"` // low, medium, high
+	AcknowledgeMinutes int       `json:"acknowledge_minutes"`
+	ResolveMinutes     int       `json:"resolve_minutes"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// MaintenanceEscalation records an SLA breach escalated to a manager
+type MaintenanceEscalation struct {
+	ID                 uint      `gorm:"primaryKey" json:"id"`
+	MaintenanceIssueID uint      `gorm:"uniqueIndex:idx_escalation_level" json:"maintenance_issue_id"`
+	Level              int       `gorm:"uniqueIndex:idx_escalation_level" json:"level"`
+	Reason             string    `json:"reason"` // acknowledge-breached, resolve-breached
+	EscalatedToID      *uint     `json:"escalated_to_id"`
+	DueAt              time.Time `json:"due_at"`
+	EscalatedAt        time.Time `json:"escalated_at"`
+	CreatedAt          time.Time `json:"created_at"`
+
+	// Relations
+	EscalatedTo *Staff `gorm:"foreignKey:EscalatedToID" json:"escalated_to,omitempty"`
+}
+
+// RoomAsset represents a maintained piece of equipment installed in a room
+type RoomAsset struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	RoomID       uint       `gorm:"index" json:"room_id"`
+	AssetType    string     `json:"asset_type"` // ac, tv, water-heater, door-lock
+	Name         string     `json:"name"`
+	Manufacturer string     `json:"manufacturer"`
+	ModelNumber  string     `json:"model_number"`
+	SerialNumber string     `json:"serial_number"`
+	InstalledAt  *time.Time `json:"installed_at"`
+	Status       string     `json:"status"` // active, retired
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	Room       Room                            `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Schedules  []PreventiveMaintenanceSchedule `gorm:"foreignKey:AssetID" json:"schedules,omitempty"`
+	WorkOrders []MaintenanceWorkOrder          `gorm:"foreignKey:AssetID" json:"work_orders,omitempty"`
+	Issues     []MaintenanceIssue              `gorm:"foreignKey:AssetID" json:"issues,omitempty"`
+}
+
+// PreventiveMaintenanceSchedule is a recurring service task for an asset
+type PreventiveMaintenanceSchedule struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	AssetID          uint       `gorm:"index" json:"asset_id"`
+	Task             string     `json:"task"` // e.g. "Clean filters and check refrigerant"
+	IntervalDays     int        `json:"interval_days"`
+	ToleranceDays    int        `json:"tolerance_days"` // how far from the due date the work may move to find a vacant night
+	EstimatedMinutes int        `json:"estimated_minutes"`
+	LastPerformedAt  *time.Time `json:"last_performed_at"`
+	NextDueAt        time.Time  `gorm:"index" json:"next_due_at"`
+	Active           bool       `json:"active"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Relations
+	Asset RoomAsset `gorm:"foreignKey:AssetID" json:"asset,omitempty"`
+}
+
+// MaintenanceWorkOrder is a planned piece of preventive maintenance on an
+// asset, generated from its schedule
+type MaintenanceWorkOrder struct {
+	ID               uint       `gorm:"primaryKey" json:"id"`
+	AssetID          uint       `gorm:"index" json:"asset_id"`
+	RoomID           uint       `gorm:"index" json:"room_id"`
+	ScheduleID       *uint      `gorm:"index" json:"schedule_id"`
+	Task             string     `json:"task"`
+	Status           string     `gorm:"index" json:"status"` // unscheduled, scheduled, in-progress, completed, cancelled
+	DueAt            time.Time  `json:"due_at"`
+	ScheduledFor     *time.Time `gorm:"index" json:"scheduled_for"` // vacant night chosen for the work
+	EstimatedMinutes int        `json:"estimated_minutes"`
+	StartedAt        *time.Time `json:"started_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	Notes            string     `gorm:"type:text" json:"notes"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+
+	// Relations
+	Asset    RoomAsset                      `gorm:"foreignKey:AssetID" json:"asset,omitempty"`
+	Room     Room                           `gorm:"foreignKey:RoomID" json:"room,omitempty"`
+	Schedule *PreventiveMaintenanceSchedule `gorm:"foreignKey:ScheduleID" json:"schedule,omitempty"`
 }
 
 // MenuItem represents a room service menu item
@@ -181,8 +881,65 @@ type MenuItem struct {
 	Price       float64   `json:"price"`
 	Category    string    `json:"category"` // Food, Drinks
 	Available   bool      `json:"available"`
+	OutOfStock  bool      `json:"out_of_stock"` // set when an ingredient runs out; cleared on restock
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relations
+	Recipe []RecipeItem `gorm:"foreignKey:MenuItemID" json:"recipe,omitempty"`
+}
+
+// Ingredient represents a stock-tracked kitchen ingredient
+type Ingredient struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	Name              string    `gorm:"uniqueIndex" json:"name"`
+	Unit              string    `json:"unit"` // g, kg, ml, l, piece
+	StockQuantity     float64   `json:"stock_quantity"`
+	LowStockThreshold float64   `json:"low_stock_threshold"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// RecipeItem is the quantity of an ingredient used by one MenuItem
+type RecipeItem struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	MenuItemID   uint      `gorm:"uniqueIndex:idx_recipe_item" json:"menu_item_id"`
+	IngredientID uint      `gorm:"uniqueIndex:idx_recipe_item;index" json:"ingredient_id"`
+	Quantity     float64   `json:"quantity"` // in the ingredient's unit
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relations
+	Ingredient Ingredient `gorm:"foreignKey:IngredientID" json:"ingredient,omitempty"`
+}
+
+// StockMovement records every change to an ingredient's stock
+type StockMovement struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	IngredientID uint      `gorm:"index" json:"ingredient_id"`
+	Change       float64   `json:"change"` // positive for restock, negative for usage
+	BalanceAfter float64   `json:"balance_after"`
+	Reason       string    `json:"reason"` // order, restock, adjustment, waste
+	SourceType   string    `gorm:"index:idx_stock_source" json:"source_type"`
+	SourceID     uint      `gorm:"index:idx_stock_source" json:"source_id"`
+	Notes        string    `gorm:"type:text" json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// LowStockAlert is raised when an ingredient drops to its threshold
+type LowStockAlert struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	IngredientID  uint       `gorm:"index" json:"ingredient_id"`
+	StockQuantity float64    `json:"stock_quantity"`
+	Threshold     float64    `json:"threshold"`
+	Status        string     `gorm:"index" json:"status"` // open, resolved
+	RaisedAt      time.Time  `json:"raised_at"`
+	ResolvedAt    *time.Time `json:"resolved_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+
+	// Relations
+	Ingredient Ingredient `gorm:"foreignKey:IngredientID" json:"ingredient,omitempty"`
 }
 
 // Staff represents hotel staff members
@@ -195,6 +952,31 @@ type Staff struct {
 	Status    string    `json:"status"` // active, inactive
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Shifts []StaffShift `gorm:"foreignKey:StaffID" json:"shifts,omitempty"`
+}
+
+// StaffShift is one rostered or worked shift. Unplanned shifts are created
+// when staff clock in without one on the roster.
+type StaffShift struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	StaffID      uint       `gorm:"index" json:"staff_id"`
+	Role         string     `gorm:"index" json:"role"` // role worked on this shift, defaults to the staff role
+	ShiftDate    time.Time  `gorm:"type:date;index" json:"shift_date"`
+	StartsAt     time.Time  `json:"starts_at"`
+	EndsAt       time.Time  `json:"ends_at"`
+	BreakMinutes int        `json:"break_minutes"` // unpaid break deducted from worked hours
+	Unplanned    bool       `json:"unplanned"`
+	ClockInAt    *time.Time `gorm:"index" json:"clock_in_at"`
+	ClockOutAt   *time.Time `json:"clock_out_at"`
+	Status       string     `gorm:"index" json:"status"` // scheduled, on-duty, completed, missed, cancelled
+	Notes        string     `gorm:"type:text" json:"notes"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+
+	// Relations
+	Staff Staff `gorm:"foreignKey:StaffID" json:"staff,omitempty"`
 }
 
 // CheckIn represents a guest check-in record
@@ -0,0 +1,199 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Occupant age categories
+const (
+	OccupantAdult = "adult"
+	OccupantChild = "child"
+)
+
+// Occupant and check-in errors
+var (
+	ErrOverCapacity         = errors.New("occupants exceed room capacity")
+	ErrOccupantIDIncomplete = errors.New("occupant ID type, number and nationality are required")
+	ErrOccupantsUnverified  = errors.New("not all adult occupants have verified ID")
+	ErrPrimaryOccupant      = errors.New("the primary occupant cannot be removed")
+	ErrReservationNotReady  = errors.New("reservation is not confirmed")
+	ErrOverbookedNoRoom     = errors.New("overbooked reservation has no room yet")
+)
+
+// EnsurePrimaryOccupant returns the reservation's primary occupant, creating
+// it from the booking guest's details the first time.
+func EnsurePrimaryOccupant(tx *gorm.DB, reservation *Reservation) (*ReservationOccupant, error) {
+	var primary ReservationOccupant
+	result := tx.Where("reservation_id = ? AND is_primary = ?", reservation.ID, true).Limit(1).Find(&primary)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected > 0 {
+		return &primary, nil
+	}
+
+	var guest Guest
+	if err := tx.First(&guest, reservation.GuestID).Error; err != nil {
+		return nil, err
+	}
+	primary = ReservationOccupant{
+		ReservationID: reservation.ID,
+		GuestID:       &guest.ID,
+		IsPrimary:     true,
+		Name:          guest.Name,
+		AgeCategory:   OccupantAdult,
+		Nationality:   guest.Nationality,
+		IDType:        guest.IDType,
+		IDNumber:      guest.IDNumber,
+	}
+	return &primary, tx.Create(&primary).Error
+}
+
+// AddOccupant adds a person to the reservation, keeping the total within the
+// room's capacity. The primary occupant is created first if needed.
+func AddOccupant(tx *gorm.DB, reservation *Reservation, occupant *ReservationOccupant) error {
+	if _, err := EnsurePrimaryOccupant(tx, reservation); err != nil {
+		return err
+	}
+	if err := checkOccupantCapacity(tx, reservation, 1); err != nil {
+		return err
+	}
+
+	occupant.ReservationID = reservation.ID
+	occupant.IsPrimary = false
+	occupant.VerifiedAt = nil
+	occupant.VerifiedByID = nil
+	if occupant.AgeCategory == "" {
+		occupant.AgeCategory = OccupantAdult
+	}
+	return tx.Create(occupant).Error
+}
+
+// checkOccupantCapacity reports ErrOverCapacity if adding more occupants to
+// the reservation would exceed its room's capacity
+func checkOccupantCapacity(tx *gorm.DB, reservation *Reservation, more int) error {
+	var room Room
+	if err := tx.First(&room, reservation.RoomID).Error; err != nil {
+		return err
+	}
+	var count int64
+	err := tx.Model(&ReservationOccupant{}).Where("reservation_id = ?", reservation.ID).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if int(count)+more > room.Capacity {
+		return fmt.Errorf("%w: room %s sleeps %d", ErrOverCapacity, room.RoomNumber, room.Capacity)
+	}
+	return nil
+}
+
+// UpdateOccupantID changes an occupant's ID details. Any change to them
+// clears the verification, which must then be done again.
+func UpdateOccupantID(tx *gorm.DB, occupant *ReservationOccupant, idType, idNumber, nationality string) error {
+	if occupant.IDType == idType && occupant.IDNumber == idNumber && occupant.Nationality == nationality {
+		return nil
+	}
+	occupant.IDType, occupant.IDNumber, occupant.Nationality = idType, idNumber, nationality
+	occupant.VerifiedAt, occupant.VerifiedByID = nil, nil
+	return tx.Model(occupant).Updates(map[string]interface{}{
+		"id_type":        idType,
+		"id_number":      idNumber,
+		"nationality":    nationality,
+		"verified_at":    nil,
+		"verified_by_id": nil,
+	}).Error
+}
+
+// VerifyOccupant records that staff have checked the occupant's ID document.
+// Adults need ID type, number and nationality on file first.
+func VerifyOccupant(tx *gorm.DB, occupant *ReservationOccupant, staffID uint, now time.Time) error {
+	if occupant.AgeCategory == OccupantAdult && !occupantIDComplete(*occupant) {
+		return ErrOccupantIDIncomplete
+	}
+	occupant.VerifiedAt = &now
+	occupant.VerifiedByID = &staffID
+	return tx.Model(occupant).Updates(map[string]interface{}{
+		"verified_at":    now,
+		"verified_by_id": staffID,
+	}).Error
+}
+
+// RemoveOccupant deletes a non-primary occupant
+func RemoveOccupant(tx *gorm.DB, occupant *ReservationOccupant) error {
+	if occupant.IsPrimary {
+		return ErrPrimaryOccupant
+	}
+	return tx.Delete(occupant).Error
+}
+
+// UnverifiedOccupants returns the reservation's adults whose ID has not been
+// verified. Children do not need ID.
+func UnverifiedOccupants(tx *gorm.DB, reservationID uint) ([]ReservationOccupant, error) {
+	var occupants []ReservationOccupant
+	err := tx.Where("reservation_id = ? AND age_category = ? AND verified_at IS NULL", reservationID, OccupantAdult).
+		Order("is_primary DESC, id").Find(&occupants).Error
+	return occupants, err
+}
+
+// CheckInReservation checks a confirmed reservation in once every adult
+// occupant's ID is verified and the party fits the room. An overbooked
+// reservation must be given a room first. It records the check-in, marks
+// the reservation checked in and the room occupied.
+func CheckInReservation(db *gorm.DB, checkIn *CheckIn, now time.Time) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		var reservation Reservation
+		if err := tx.First(&reservation, checkIn.ReservationID).Error; err != nil {
+			return err
+		}
+		if reservation.Status != ReservationStatusConfirmed {
+			return fmt.Errorf("%w: %s", ErrReservationNotReady, reservation.Status)
+		}
+		if reservation.Overbooked {
+			return ErrOverbookedNoRoom
+		}
+		if _, err := EnsurePrimaryOccupant(tx, &reservation); err != nil {
+			return err
+		}
+		if err := checkOccupantCapacity(tx, &reservation, 0); err != nil {
+			return err
+		}
+
+		unverified, err := UnverifiedOccupants(tx, reservation.ID)
+		if err != nil {
+			return err
+		}
+		if len(unverified) > 0 {
+			names := make([]string, len(unverified))
+			for i, occupant := range unverified {
+				names[i] = occupant.Name
+			}
+			return fmt.Errorf("%w: %s", ErrOccupantsUnverified, strings.Join(names, ", "))
+		}
+
+		checkIn.GuestID = reservation.GuestID
+		checkIn.RoomID = reservation.RoomID
+		checkIn.IDVerified = true
+		if checkIn.CheckInTime.IsZero() {
+			checkIn.CheckInTime = now
+		}
+		if err := tx.Create(checkIn).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&reservation).Update("status", ReservationStatusCheckedIn).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Room{}).Where("id = ?", reservation.RoomID).
+			Update("status", RoomStatusOccupied).Error
+	})
+}
+
+func occupantIDComplete(occupant ReservationOccupant) bool {
+	return strings.TrimSpace(occupant.IDType) != "" &&
+		strings.TrimSpace(occupant.IDNumber) != "" &&
+		strings.TrimSpace(occupant.Nationality) != ""
+}
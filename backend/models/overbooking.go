@@ -0,0 +1,293 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// dateKey is the layout used to key nights
+const dateKey = "2006-01-02"
+
+// ErrSoldOut is returned when a room type has no sellable rooms left, even
+// counting the overbooking allowance, on some night of the stay
+var ErrSoldOut = errors.New("room type is sold out for the stay")
+
+// InventoryDay is the inventory of one room type for one night
+type InventoryDay struct {
+	Date       time.Time `json:"date"`
+	RoomType   string    `json:"room_type"`
+	Physical   int       `json:"physical"`     // rooms of the type
+	OutOfOrder int       `json:"out_of_order"` // rooms currently in maintenance
+	Booked     int       `json:"booked"`       // active reservations for the night
+	GroupHeld  int       `json:"group_held"`   // unclaimed group allotment
+	Allowance  int       `json:"allowance"`    // overbooking allowed
+	Sellable   int       `json:"sellable"`     // still for sale, including the allowance
+	Oversold   int       `json:"oversold"`     // booked beyond rooms in service
+}
+
+// SetOverbookingAllowance sets the allowance for a room type on every night
+// from from up to, but not including, to
+func SetOverbookingAllowance(tx *gorm.DB, roomType string, from, to time.Time, rooms int, notes string) ([]OverbookingAllowance, error) {
+	var allowances []OverbookingAllowance
+	for night := atHour(from, 0); night.Before(atHour(to, 0)); night = night.AddDate(0, 0, 1) {
+		var allowance OverbookingAllowance
+		result := tx.Where("room_type = ? AND date = ?", roomType, night).Limit(1).Find(&allowance)
+		if result.Error != nil {
+			return nil, result.Error
+		}
+		if result.RowsAffected == 0 {
+			allowance = OverbookingAllowance{RoomType: roomType, Date: night, Rooms: rooms, Notes: notes}
+			if err := tx.Create(&allowance).Error; err != nil {
+				return nil, err
+			}
+		} else {
+			allowance.Rooms, allowance.Notes = rooms, notes
+			err := tx.Model(&allowance).Updates(map[string]interface{}{"rooms": rooms, "notes": notes}).Error
+			if err != nil {
+				return nil, err
+			}
+		}
+		allowances = append(allowances, allowance)
+	}
+	return allowances, nil
+}
+
+// InventoryCalendar returns the inventory of each room type (all types if
+// none are given) for every night from from up to, but not including, to.
+// Out-of-order rooms are counted from their current status.
+func InventoryCalendar(tx *gorm.DB, from, to time.Time, roomTypes ...string) ([]InventoryDay, error) {
+	from, to = atHour(from, 0), atHour(to, 0)
+
+	query := tx.Model(&Room{})
+	if len(roomTypes) > 0 {
+		query = query.Where("room_type IN ?", roomTypes)
+	}
+	var rooms []Room
+	if err := query.Find(&rooms).Error; err != nil {
+		return nil, err
+	}
+	physical, outOfOrder := map[string]int{}, map[string]int{}
+	roomTypeOf := make(map[uint]string, len(rooms))
+	roomIDs := make([]uint, 0, len(rooms))
+	for _, room := range rooms {
+		physical[room.RoomType]++
+		if room.Status == RoomStatusMaintenance {
+			outOfOrder[room.RoomType]++
+		}
+		roomTypeOf[room.ID] = room.RoomType
+		roomIDs = append(roomIDs, room.ID)
+	}
+	types := make([]string, 0, len(physical))
+	for roomType := range physical {
+		types = append(types, roomType)
+	}
+	sort.Strings(types)
+
+	var reservations []Reservation
+	err := tx.Where("room_id IN ? AND status IN ? AND check_in_date < ? AND check_out_date > ?",
+		roomIDs, ActiveReservationStatuses, to, from).Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	booked := map[string]int{}
+	for _, reservation := range reservations {
+		roomType := roomTypeOf[reservation.RoomID]
+		for night := atHour(reservation.CheckInDate, 0); night.Before(atHour(reservation.CheckOutDate, 0)); night = night.AddDate(0, 0, 1) {
+			booked[roomType+"|"+night.Format(dateKey)]++
+		}
+	}
+
+	var allowances []OverbookingAllowance
+	err = tx.Where("room_type IN ? AND date >= ? AND date < ?", types, from, to).Find(&allowances).Error
+	if err != nil {
+		return nil, err
+	}
+	allowed := map[string]int{}
+	for _, allowance := range allowances {
+		allowed[allowance.RoomType+"|"+allowance.Date.Format(dateKey)] = allowance.Rooms
+	}
+
+	var days []InventoryDay
+	for night := from; night.Before(to); night = night.AddDate(0, 0, 1) {
+		for _, roomType := range types {
+			key := roomType + "|" + night.Format(dateKey)
+			held, err := UnclaimedGroupRooms(tx, roomType, night, night.AddDate(0, 0, 1), 0)
+			if err != nil {
+				return nil, err
+			}
+			day := InventoryDay{
+				Date:       night,
+				RoomType:   roomType,
+				Physical:   physical[roomType],
+				OutOfOrder: outOfOrder[roomType],
+				Booked:     booked[key],
+				GroupHeld:  held,
+				Allowance:  allowed[key],
+			}
+			inService := day.Physical - day.OutOfOrder
+			day.Sellable = int(math.Max(0, float64(inService+day.Allowance-day.Booked-day.GroupHeld)))
+			day.Oversold = int(math.Max(0, float64(day.Booked-inService)))
+			days = append(days, day)
+		}
+	}
+	return days, nil
+}
+
+// ReserveRoomOfType books the reservation into a free room of the type. If
+// none is free but every night still has sellable inventory thanks to the
+// overbooking allowance, the reservation is placed in the first room of the
+// type and marked Overbooked; it moves to a real room when one frees up, or
+// appears on the walk-out report. Unless a price was given, the stay is
+// priced under the reservation's rate plan. A booking ID is generated if
+// missing.
+func ReserveRoomOfType(tx *gorm.DB, reservation *Reservation, roomType string, guests int, now time.Time) error {
+	if !reservation.CheckOutDate.After(reservation.CheckInDate) {
+		return ErrInvalidStayDates
+	}
+	days, err := InventoryCalendar(tx, reservation.CheckInDate, reservation.CheckOutDate, roomType)
+	if err != nil {
+		return err
+	}
+	for _, day := range days {
+		if day.Sellable <= 0 {
+			return fmt.Errorf("%w: %s on %s", ErrSoldOut, roomType, day.Date.Format(dateKey))
+		}
+	}
+
+	room, err := findFreeRoomOfType(tx, roomType, guests, reservation.CheckInDate, reservation.CheckOutDate, 0)
+	reservation.Overbooked = errors.Is(err, ErrNoRoomAvailable)
+	if reservation.Overbooked {
+		room = &Room{}
+		err = tx.Where("room_type = ? AND capacity >= ? AND status <> ?", roomType, guests, RoomStatusMaintenance).
+			Order("floor, room_number").First(room).Error
+	}
+	if err != nil {
+		return err
+	}
+
+	reservation.RoomID = room.ID
+	reservation.Nights = int(math.Round(atHour(reservation.CheckOutDate, 0).Sub(atHour(reservation.CheckInDate, 0)).Hours() / 24))
+	if reservation.TotalPrice == 0 {
+		if _, err := PriceReservation(tx, reservation, guests, now); err != nil {
+			return err
+		}
+	}
+	if reservation.Status == "" {
+		reservation.Status = ReservationStatusPending
+	}
+	if reservation.BookingID != "" {
+		return tx.Create(reservation).Error
+	}
+	return InsertWithGeneratedID(tx, BookingIDScheme, now, func(id string) { reservation.BookingID = id }, reservation)
+}
+
+// relocateOverbooked moves overbooked reservations of the type overlapping
+// from–to into rooms that have become free, oldest booking first, recording
+// a room change when the room is not their placeholder
+func relocateOverbooked(tx *gorm.DB, roomType string, from, to, now time.Time) error {
+	var reservations []Reservation
+	err := tx.Joins("JOIN rooms ON rooms.id = reservations.room_id").
+		Where("reservations.overbooked = ? AND reservations.status IN ? AND rooms.room_type = ? AND reservations.check_in_date < ? AND reservations.check_out_date > ?",
+			true, ActiveReservationStatuses, roomType, to, from).
+		Order("reservations.created_at, reservations.id").Find(&reservations).Error
+	if err != nil {
+		return err
+	}
+	for i := range reservations {
+		room, err := findFreeRoomOfType(tx, roomType, 0, reservations[i].CheckInDate, reservations[i].CheckOutDate, reservations[i].ID)
+		if errors.Is(err, ErrNoRoomAvailable) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if room.ID != reservations[i].RoomID {
+			if err := recordRoomMove(tx, &reservations[i], room.ID, "Overbooking relocated", now); err != nil {
+				return err
+			}
+		}
+		err = tx.Model(&reservations[i]).Updates(map[string]interface{}{
+			"room_id":    room.ID,
+			"overbooked": false,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WalkOutNight lists, for one oversold night and room type, the arriving
+// reservations that would be walked to another hotel if every booked guest
+// turned up
+type WalkOutNight struct {
+	Date       time.Time     `json:"date"`
+	RoomType   string        `json:"room_type"`
+	InService  int           `json:"in_service"`
+	Booked     int           `json:"booked"`
+	Oversold   int           `json:"oversold"`
+	Walk       []Reservation `json:"walk"`
+	Unresolved int           `json:"unresolved"` // oversold rooms with no arrival left to walk
+}
+
+// WalkOutReport finds every oversold night from from up to, but not
+// including, to and picks the reservations to relocate. Only guests arriving
+// that night are walked; guests already in house stay. Pending bookings go
+// before confirmed ones, overbooked ones before the rest, and the most
+// recently made first. Group reservations are walked last.
+func WalkOutReport(tx *gorm.DB, from, to time.Time) ([]WalkOutNight, error) {
+	days, err := InventoryCalendar(tx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var report []WalkOutNight
+	for _, day := range days {
+		if day.Oversold == 0 {
+			continue
+		}
+		var arrivals []Reservation
+		err := tx.Joins("JOIN rooms ON rooms.id = reservations.room_id").
+			Where("rooms.room_type = ? AND reservations.status IN ? AND reservations.check_in_date >= ? AND reservations.check_in_date < ?",
+				day.RoomType, []string{ReservationStatusPending, ReservationStatusConfirmed}, day.Date, day.Date.AddDate(0, 0, 1)).
+			Preload("Guest").Preload("Room").
+			Find(&arrivals).Error
+		if err != nil {
+			return nil, err
+		}
+		sort.SliceStable(arrivals, func(i, j int) bool {
+			a, b := arrivals[i], arrivals[j]
+			if (a.GroupBookingID == nil) != (b.GroupBookingID == nil) {
+				return a.GroupBookingID == nil
+			}
+			if (a.Status == ReservationStatusPending) != (b.Status == ReservationStatusPending) {
+				return a.Status == ReservationStatusPending
+			}
+			if a.Overbooked != b.Overbooked {
+				return a.Overbooked
+			}
+			return a.CreatedAt.After(b.CreatedAt)
+		})
+
+		night := WalkOutNight{
+			Date:      day.Date,
+			RoomType:  day.RoomType,
+			InService: day.Physical - day.OutOfOrder,
+			Booked:    day.Booked,
+			Oversold:  day.Oversold,
+		}
+		walk := day.Oversold
+		if walk > len(arrivals) {
+			night.Unresolved = walk - len(arrivals)
+			walk = len(arrivals)
+		}
+		night.Walk = arrivals[:walk]
+		report = append(report, night)
+	}
+	return report, nil
+}
@@ -0,0 +1,345 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Room asset types
+const (
+	AssetTypeAC          = "ac"
+	AssetTypeTV          = "tv"
+	AssetTypeWaterHeater = "water-heater"
+	AssetTypeDoorLock    = "door-lock"
+)
+
+// Room asset statuses
+const (
+	AssetStatusActive  = "active"
+	AssetStatusRetired = "retired"
+)
+
+// Maintenance work order statuses
+const (
+	WorkOrderStatusUnscheduled = "unscheduled"
+	WorkOrderStatusScheduled   = "scheduled"
+	WorkOrderStatusInProgress  = "in-progress"
+	WorkOrderStatusCompleted   = "completed"
+	WorkOrderStatusCancelled   = "cancelled"
+)
+
+// DefaultScheduleToleranceDays is how far either side of the due date a work
+// order may move to find a vacant night when the schedule does not say
+const DefaultScheduleToleranceDays = 7
+
+// ErrRoomOccupied is returned when work is planned on a night the room is booked
+var ErrRoomOccupied = errors.New("room is occupied that night")
+
+// issueAssetTypes maps guest-reported issue types to the asset that usually
+// causes them, so breakdowns count against the right unit
+var issueAssetTypes = map[string]string{
+	"ac":        AssetTypeAC,
+	"tv":        AssetTypeTV,
+	"plumbing":  AssetTypeWaterHeater,
+	"door-lock": AssetTypeDoorLock,
+}
+
+var workOrderTransitions = map[string][]string{
+	WorkOrderStatusUnscheduled: {WorkOrderStatusScheduled, WorkOrderStatusCancelled},
+	WorkOrderStatusScheduled:   {WorkOrderStatusScheduled, WorkOrderStatusUnscheduled, WorkOrderStatusInProgress, WorkOrderStatusCancelled},
+	WorkOrderStatusInProgress:  {WorkOrderStatusCompleted, WorkOrderStatusCancelled},
+}
+
+var openWorkOrderStatuses = []string{
+	WorkOrderStatusUnscheduled,
+	WorkOrderStatusScheduled,
+	WorkOrderStatusInProgress,
+}
+
+// MatchIssueAsset links an issue to the room's asset of the matching type
+// when the reporter did not name one. Rooms with several assets of that
+// type are left unlinked for maintenance to pick.
+func MatchIssueAsset(tx *gorm.DB, issue *MaintenanceIssue) error {
+	assetType, ok := issueAssetTypes[issue.IssueType]
+	if issue.AssetID != nil || !ok {
+		return nil
+	}
+
+	var assets []RoomAsset
+	err := tx.Where("room_id = ? AND asset_type = ? AND status = ?", issue.RoomID, assetType, AssetStatusActive).
+		Limit(2).Find(&assets).Error
+	if err != nil {
+		return err
+	}
+	if len(assets) == 1 {
+		issue.AssetID = &assets[0].ID
+	}
+	return nil
+}
+
+// FindVacantNight returns the night closest to due, within toleranceDays
+// either side and not before today, on which the room has no active
+// reservation. Earlier nights win ties. Work already overdue is searched for
+// forward from today. It returns nil if every night is booked.
+func FindVacantNight(tx *gorm.DB, roomID uint, due time.Time, toleranceDays int, today time.Time) (*time.Time, error) {
+	due = atHour(due, 0)
+	today = atHour(today, 0)
+	if due.Before(today) {
+		due = today
+	}
+	for offset := 0; offset <= toleranceDays; offset++ {
+		for _, day := range []time.Time{due.AddDate(0, 0, -offset), due.AddDate(0, 0, offset)} {
+			if day.Before(today) {
+				continue
+			}
+			free, err := RoomIsFree(tx, roomID, day, day.AddDate(0, 0, 1), 0)
+			if err != nil {
+				return nil, err
+			}
+			if free {
+				return &day, nil
+			}
+			if offset == 0 {
+				break
+			}
+		}
+	}
+	return nil, nil
+}
+
+// GeneratePreventiveWorkOrders creates a work order for every active schedule
+// falling due within horizon of now, placed on a vacant night, and moves
+// scheduled work whose night has since been booked. Schedules with an open
+// work order are skipped, so the job can run daily.
+func GeneratePreventiveWorkOrders(tx *gorm.DB, now time.Time, horizon time.Duration) ([]MaintenanceWorkOrder, error) {
+	if err := rescheduleBookedWorkOrders(tx, now); err != nil {
+		return nil, err
+	}
+
+	var schedules []PreventiveMaintenanceSchedule
+	err := tx.Joins("Asset").
+		Where("preventive_maintenance_schedules.active = ? AND preventive_maintenance_schedules.next_due_at < ? AND \"Asset\".status = ?",
+			true, now.Add(horizon), AssetStatusActive).
+		Where("NOT EXISTS (SELECT 1 FROM maintenance_work_orders w WHERE w.schedule_id = preventive_maintenance_schedules.id AND w.status IN ?)",
+			openWorkOrderStatuses).
+		Order("preventive_maintenance_schedules.next_due_at").
+		Find(&schedules).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var orders []MaintenanceWorkOrder
+	for _, schedule := range schedules {
+		tolerance := schedule.ToleranceDays
+		if tolerance <= 0 {
+			tolerance = DefaultScheduleToleranceDays
+		}
+		night, err := FindVacantNight(tx, schedule.Asset.RoomID, schedule.NextDueAt, tolerance, now)
+		if err != nil {
+			return nil, err
+		}
+
+		scheduleID := schedule.ID
+		order := MaintenanceWorkOrder{
+			AssetID:          schedule.AssetID,
+			RoomID:           schedule.Asset.RoomID,
+			ScheduleID:       &scheduleID,
+			Task:             schedule.Task,
+			Status:           WorkOrderStatusUnscheduled,
+			DueAt:            schedule.NextDueAt,
+			ScheduledFor:     night,
+			EstimatedMinutes: schedule.EstimatedMinutes,
+		}
+		if night != nil {
+			order.Status = WorkOrderStatusScheduled
+		}
+		if err := tx.Create(&order).Error; err != nil {
+			return nil, err
+		}
+		orders = append(orders, order)
+	}
+	return orders, nil
+}
+
+// rescheduleBookedWorkOrders moves scheduled work off nights that a
+// reservation has taken since it was planned
+func rescheduleBookedWorkOrders(tx *gorm.DB, now time.Time) error {
+	var orders []MaintenanceWorkOrder
+	err := tx.Preload("Schedule").
+		Where("status = ? AND scheduled_for >= ?", WorkOrderStatusScheduled, atHour(now, 0)).
+		Find(&orders).Error
+	if err != nil {
+		return err
+	}
+
+	for i := range orders {
+		order := &orders[i]
+		night := *order.ScheduledFor
+		free, err := RoomIsFree(tx, order.RoomID, night, night.AddDate(0, 0, 1), 0)
+		if err != nil {
+			return err
+		}
+		if free {
+			continue
+		}
+
+		tolerance := DefaultScheduleToleranceDays
+		if order.Schedule != nil && order.Schedule.ToleranceDays > 0 {
+			tolerance = order.Schedule.ToleranceDays
+		}
+		moved, err := FindVacantNight(tx, order.RoomID, order.DueAt, tolerance, now)
+		if err != nil {
+			return err
+		}
+		status := WorkOrderStatusScheduled
+		if moved == nil {
+			status = WorkOrderStatusUnscheduled
+		}
+		err = tx.Model(order).Updates(map[string]interface{}{
+			"status":        status,
+			"scheduled_for": moved,
+		}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScheduleWorkOrder plans a work order for a specific night chosen by
+// maintenance, which must be vacant
+func ScheduleWorkOrder(tx *gorm.DB, order *MaintenanceWorkOrder, night time.Time) error {
+	if !canTransition(workOrderTransitions, order.Status, WorkOrderStatusScheduled) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, order.Status, WorkOrderStatusScheduled)
+	}
+
+	night = atHour(night, 0)
+	free, err := RoomIsFree(tx, order.RoomID, night, night.AddDate(0, 0, 1), 0)
+	if err != nil {
+		return err
+	}
+	if !free {
+		return ErrRoomOccupied
+	}
+
+	order.Status = WorkOrderStatusScheduled
+	order.ScheduledFor = &night
+	return tx.Model(order).Updates(map[string]interface{}{
+		"status":        WorkOrderStatusScheduled,
+		"scheduled_for": night,
+	}).Error
+}
+
+// AdvanceWorkOrder moves a work order to the given status. Completing it
+// rolls the schedule forward by its interval from the completion time.
+func AdvanceWorkOrder(tx *gorm.DB, order *MaintenanceWorkOrder, status string, now time.Time) error {
+	if status == WorkOrderStatusScheduled || !canTransition(workOrderTransitions, order.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, order.Status, status)
+	}
+
+	updates := map[string]interface{}{"status": status}
+	switch status {
+	case WorkOrderStatusInProgress:
+		updates["started_at"] = now
+		order.StartedAt = &now
+	case WorkOrderStatusCompleted:
+		updates["completed_at"] = now
+		order.CompletedAt = &now
+	}
+	order.Status = status
+	if err := tx.Model(order).Updates(updates).Error; err != nil {
+		return err
+	}
+
+	if status != WorkOrderStatusCompleted || order.ScheduleID == nil {
+		return nil
+	}
+	var schedule PreventiveMaintenanceSchedule
+	if err := tx.First(&schedule, *order.ScheduleID).Error; err != nil {
+		return err
+	}
+	return tx.Model(&schedule).Updates(map[string]interface{}{
+		"last_performed_at": now,
+		"next_due_at":       now.AddDate(0, 0, schedule.IntervalDays),
+	}).Error
+}
+
+// AssetHistory summarises how often an asset has broken down and been serviced
+type AssetHistory struct {
+	AssetID                   uint       `json:"asset_id"`
+	Breakdowns                int        `json:"breakdowns"`
+	LastBreakdownAt           *time.Time `json:"last_breakdown_at"`
+	MeanDaysBetweenBreakdowns *float64   `json:"mean_days_between_breakdowns"` // nil until there are two breakdowns
+	BreakdownsPerYear         float64    `json:"breakdowns_per_year"`          // since installation, or since the first record
+	PreventiveCompleted       int        `json:"preventive_completed"`
+	LastServicedAt            *time.Time `json:"last_serviced_at"`
+}
+
+// CalculateAssetHistory loads an asset's breakdowns and work orders, oldest
+// first, and summarises them as of now.
+func CalculateAssetHistory(tx *gorm.DB, assetID uint, now time.Time) (*RoomAsset, *AssetHistory, error) {
+	var asset RoomAsset
+	err := tx.Preload("Issues", func(db *gorm.DB) *gorm.DB { return db.Order("reported_at") }).
+		Preload("WorkOrders", func(db *gorm.DB) *gorm.DB { return db.Order("due_at") }).
+		First(&asset, assetID).Error
+	if err != nil {
+		return nil, nil, err
+	}
+
+	history := &AssetHistory{AssetID: asset.ID, Breakdowns: len(asset.Issues)}
+	since := asset.CreatedAt
+	if asset.InstalledAt != nil {
+		since = *asset.InstalledAt
+	}
+	if n := len(asset.Issues); n > 0 {
+		first, last := asset.Issues[0].ReportedAt, asset.Issues[n-1].ReportedAt
+		history.LastBreakdownAt = &last
+		if n > 1 {
+			mean := math.Round(last.Sub(first).Hours()/24/float64(n-1)*10) / 10
+			history.MeanDaysBetweenBreakdowns = &mean
+		}
+		if first.Before(since) {
+			since = first
+		}
+	}
+	if years := now.Sub(since).Hours() / 24 / 365; years > 0 {
+		history.BreakdownsPerYear = math.Round(float64(history.Breakdowns)/years*100) / 100
+	}
+
+	for _, order := range asset.WorkOrders {
+		if order.Status != WorkOrderStatusCompleted {
+			continue
+		}
+		history.PreventiveCompleted++
+		if history.LastServicedAt == nil || order.CompletedAt.After(*history.LastServicedAt) {
+			history.LastServicedAt = order.CompletedAt
+		}
+	}
+	return &asset, history, nil
+}
+
+// AssetBreakdownCount is the number of breakdowns of one asset in a period
+type AssetBreakdownCount struct {
+	AssetID    uint   `json:"asset_id"`
+	RoomID     uint   `json:"room_id"`
+	AssetType  string `json:"asset_type"`
+	Breakdowns int64  `json:"breakdowns"`
+}
+
+// AssetBreakdownCounts ranks assets by issues reported against them between
+// from and to, most frequent first
+func AssetBreakdownCounts(tx *gorm.DB, from, to time.Time) ([]AssetBreakdownCount, error) {
+	var counts []AssetBreakdownCount
+	err := tx.Model(&MaintenanceIssue{}).
+		Select("room_assets.id AS asset_id, room_assets.room_id, room_assets.asset_type, COUNT(*) AS breakdowns").
+		Joins("JOIN room_assets ON room_assets.id = maintenance_issues.asset_id").
+		Where("maintenance_issues.reported_at >= ? AND maintenance_issues.reported_at < ?", from, to).
+		Group("room_assets.id, room_assets.room_id, room_assets.asset_type").
+		Order("breakdowns DESC").
+		Scan(&counts).Error
+	return counts, err
+}
@@ -0,0 +1,232 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Discount types
+const (
+	DiscountPercent    = "percent"     // percent off the room total
+	DiscountFixed      = "fixed"       // amount off the stay
+	DiscountPerNight   = "per-night"   // amount off each night
+	DiscountFreeNights = "free-nights" // the cheapest nights are free
+)
+
+// Promotion errors
+var (
+	ErrPromotionNotFound      = errors.New("promotion code not found")
+	ErrPromotionNotValid      = errors.New("promotion code is not valid now")
+	ErrPromotionNotEligible   = errors.New("stay is not eligible for the promotion")
+	ErrPromotionUsedUp        = errors.New("promotion code has reached its usage limit")
+	ErrPromotionNotCombinable = errors.New("promotion cannot be combined with a corporate discount")
+)
+
+// StayRequest is a stay to be priced for a guest
+type StayRequest struct {
+	RatePlan      string // the guest's corporate plan, or BAR, if empty
+	Room          Room
+	CheckInDate   time.Time
+	CheckOutDate  time.Time
+	Guests        int
+	GuestID       uint // 0 for a guest not yet known
+	PromotionCode string
+	BookedAt      time.Time   // checked against the promotion's booking window; now if zero
+	BookedNights  []NightRate // nights already booked, which keep their rate
+}
+
+// QuoteGuestStay prices a stay under its rate plan and takes off the
+// guest's corporate discount and the promotion, if a code is given. Nothing
+// is claimed; see PriceReservation.
+func QuoteGuestStay(tx *gorm.DB, req StayRequest, now time.Time) (*StayQuote, error) {
+	var guest *Guest
+	if req.GuestID != 0 {
+		guest = &Guest{}
+		if err := tx.Preload("CorporateAccount").First(guest, req.GuestID).Error; err != nil {
+			return nil, err
+		}
+	}
+	account := activeCorporateAccount(guest, now)
+
+	planCode := req.RatePlan
+	if planCode == "" && account != nil {
+		planCode = account.RatePlan
+	}
+	quote, err := QuoteStay(tx, planCode, req.Room, req.CheckInDate, req.CheckOutDate, req.Guests)
+	if err != nil {
+		return nil, err
+	}
+	keepBookedRates(quote, req.BookedNights)
+
+	remaining := quote.RoomTotal
+	if account != nil && account.DiscountPercent > 0 {
+		amount := roundMoney(remaining * account.DiscountPercent / 100)
+		quote.Discounts = append(quote.Discounts, ReservationDiscount{
+			CorporateAccountID: &account.ID,
+			Code:               account.Code,
+			Description:        fmt.Sprintf("%s corporate discount (%g%%)", account.Name, account.DiscountPercent),
+			DiscountType:       DiscountPercent,
+			Amount:             amount,
+		})
+		remaining -= amount
+	}
+
+	if strings.TrimSpace(req.PromotionCode) != "" {
+		promo, err := FindPromotion(tx, req.PromotionCode)
+		if err != nil {
+			return nil, err
+		}
+		bookedAt := req.BookedAt
+		if bookedAt.IsZero() {
+			bookedAt = now
+		}
+		if err := checkPromotion(promo, quote, guest, bookedAt); err != nil {
+			return nil, err
+		}
+		if len(quote.Discounts) > 0 && !promo.Combinable {
+			return nil, fmt.Errorf("%w: %s", ErrPromotionNotCombinable, promo.Code)
+		}
+		amount := promotionDiscount(promo, quote, remaining)
+		quote.Discounts = append(quote.Discounts, ReservationDiscount{
+			PromotionID:  &promo.ID,
+			Code:         promo.Code,
+			Description:  promo.Name,
+			DiscountType: promo.DiscountType,
+			Amount:       amount,
+		})
+	}
+
+	for _, discount := range quote.Discounts {
+		quote.DiscountTotal += discount.Amount
+	}
+	quote.DiscountTotal = roundMoney(quote.DiscountTotal)
+	quote.Total = roundMoney(quote.RoomTotal + quote.Breakfast - quote.DiscountTotal)
+	return quote, nil
+}
+
+// FindPromotion loads a promotion by code, ignoring case and surrounding
+// spaces
+func FindPromotion(tx *gorm.DB, code string) (Promotion, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	var promo Promotion
+	result := tx.Where("code = ?", code).Limit(1).Find(&promo)
+	if result.Error != nil {
+		return Promotion{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return Promotion{}, fmt.Errorf("%w: %s", ErrPromotionNotFound, code)
+	}
+	return promo, nil
+}
+
+// checkPromotion checks the promotion can be used for the quoted stay booked
+// at bookedAt by the guest, who is nil if not yet known
+func checkPromotion(promo Promotion, quote *StayQuote, guest *Guest, bookedAt time.Time) error {
+	if !promo.Active || (promo.BookFrom != nil && bookedAt.Before(*promo.BookFrom)) ||
+		(promo.BookUntil != nil && bookedAt.After(*promo.BookUntil)) {
+		return fmt.Errorf("%w: %s", ErrPromotionNotValid, promo.Code)
+	}
+	if promo.UsageLimit > 0 && promo.UsageCount >= promo.UsageLimit {
+		return fmt.Errorf("%w: %s", ErrPromotionUsedUp, promo.Code)
+	}
+	if len(quote.Nights) < promo.MinNights {
+		return fmt.Errorf("%w: needs %d nights", ErrPromotionNotEligible, promo.MinNights)
+	}
+	first, last := quote.Nights[0].Date, quote.Nights[len(quote.Nights)-1].Date
+	if (promo.StayFrom != nil && first.Before(atHour(*promo.StayFrom, 0))) ||
+		(promo.StayUntil != nil && last.After(atHour(*promo.StayUntil, 0))) {
+		return fmt.Errorf("%w: stay outside the promotion dates", ErrPromotionNotEligible)
+	}
+	if len(promo.RoomTypes) > 0 {
+		eligible := false
+		for _, roomType := range promo.RoomTypes {
+			eligible = eligible || fmt.Sprint(roomType) == quote.RoomType
+		}
+		if !eligible {
+			return fmt.Errorf("%w: not for %s rooms", ErrPromotionNotEligible, quote.RoomType)
+		}
+	}
+	if promo.CorporateAccountID != nil && (guest == nil || guest.CorporateAccountID == nil ||
+		*guest.CorporateAccountID != *promo.CorporateAccountID) {
+		return fmt.Errorf("%w: reserved for a corporate account", ErrPromotionNotEligible)
+	}
+	return nil
+}
+
+// promotionDiscount prices the promotion against what is left of the room
+// total after any corporate discount, never more than that
+func promotionDiscount(promo Promotion, quote *StayQuote, remaining float64) float64 {
+	var amount float64
+	switch promo.DiscountType {
+	case DiscountPercent:
+		amount = remaining * promo.DiscountValue / 100
+	case DiscountFixed:
+		amount = promo.DiscountValue
+	case DiscountPerNight:
+		amount = promo.DiscountValue * float64(len(quote.Nights))
+	case DiscountFreeNights:
+		rates := make([]float64, 0, len(quote.Nights))
+		for _, night := range quote.Nights {
+			rates = append(rates, night.Rate-night.Breakfast)
+		}
+		sort.Float64s(rates)
+		for i := 0; i < int(promo.DiscountValue) && i < len(rates); i++ {
+			amount += rates[i]
+		}
+		if quote.RoomTotal > 0 {
+			amount *= remaining / quote.RoomTotal
+		}
+	}
+	if amount > remaining {
+		amount = remaining
+	}
+	return roundMoney(amount)
+}
+
+// activeCorporateAccount returns the guest's corporate account if it is
+// active and under contract, or nil
+func activeCorporateAccount(guest *Guest, now time.Time) *CorporateAccount {
+	if guest == nil || guest.CorporateAccount == nil {
+		return nil
+	}
+	account := guest.CorporateAccount
+	if !account.Active || (account.ContractStart != nil && now.Before(*account.ContractStart)) ||
+		(account.ContractEnd != nil && now.After(*account.ContractEnd)) {
+		return nil
+	}
+	return account
+}
+
+// claimPromotions takes one use of each promotion among the discounts,
+// failing if another booking took the last one first
+func claimPromotions(tx *gorm.DB, discounts []ReservationDiscount) error {
+	for _, discount := range discounts {
+		if discount.PromotionID == nil {
+			continue
+		}
+		result := tx.Model(&Promotion{}).
+			Where("id = ? AND (usage_limit = 0 OR usage_count < usage_limit)", *discount.PromotionID).
+			UpdateColumn("usage_count", gorm.Expr("usage_count + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("%w: %s", ErrPromotionUsedUp, discount.Code)
+		}
+	}
+	return nil
+}
+
+// releasePromotions gives back the promotion uses of a reservation that was
+// cancelled or whose hold lapsed
+func releasePromotions(tx *gorm.DB, reservationID uint) error {
+	used := tx.Model(&ReservationDiscount{}).Select("promotion_id").
+		Where("reservation_id = ? AND promotion_id IS NOT NULL", reservationID)
+	return tx.Model(&Promotion{}).Where("id IN (?) AND usage_count > 0", used).
+		UpdateColumn("usage_count", gorm.Expr("usage_count - 1")).Error
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter is an in-memory token bucket per key, such as a client IP.
+// Each key may make Burst requests at once and then PerMinute a minute.
+type RateLimiter struct {
+	PerMinute float64
+	Burst     float64
+
+	mu      sync.Mutex
+	buckets map[string]*rateBucket
+	swept   time.Time
+}
+
+type rateBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a limiter allowing perMinute requests a minute per
+// key, with bursts of up to burst
+func NewRateLimiter(perMinute, burst float64) *RateLimiter {
+	return &RateLimiter{PerMinute: perMinute, Burst: burst, buckets: map[string]*rateBucket{}}
+}
+
+// Allow takes a request from the key's bucket. When the bucket is empty it
+// reports how long until the next request is allowed.
+func (l *RateLimiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &rateBucket{tokens: l.Burst, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = math.Min(l.Burst, bucket.tokens+now.Sub(bucket.last).Minutes()*l.PerMinute)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.PerMinute * float64(time.Minute))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// sweep drops buckets that have refilled, at most once a minute, so idle
+// keys do not pile up
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.swept) < time.Minute {
+		return
+	}
+	l.swept = now
+	for key, bucket := range l.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Minutes()*l.PerMinute >= l.Burst {
+			delete(l.buckets, key)
+		}
+	}
+}
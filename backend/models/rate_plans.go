@@ -0,0 +1,491 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Standard rate plan codes
+const (
+	RatePlanBAR           = "BAR"
+	RatePlanCorporate     = "CORP"
+	RatePlanNonRefundable = "NRF"
+	RatePlanBreakfast     = "BB"
+)
+
+// DefaultRatePlans are seeded on a new installation
+var DefaultRatePlans = []RatePlan{
+	{Code: RatePlanBAR, Name: "Best Available Rate", OccupancyPricing: true, Active: true},
+	{Code: RatePlanCorporate, Name: "Corporate", AdjustmentPercent: -15, Active: true},
+	{Code: RatePlanNonRefundable, Name: "Non-refundable", AdjustmentPercent: -10, NonRefundable: true, OccupancyPricing: true, Active: true},
+	{Code: RatePlanBreakfast, Name: "Bed & Breakfast", BreakfastIncluded: true, BreakfastPricePerGuest: 15, OccupancyPricing: true, Active: true},
+}
+
+// Rate plan errors
+var (
+	ErrRatePlanNotFound = errors.New("rate plan not found or inactive")
+	ErrMinNights        = errors.New("stay is shorter than the rate plan's minimum")
+)
+
+// NightRate is the price of one night under a rate plan, built up from the
+// base rate
+type NightRate struct {
+	Date                time.Time `json:"date"`
+	BaseRate            float64   `json:"base_rate"` // room rate, or a date override
+	PlanAdjustment      float64   `json:"plan_adjustment"`
+	Occupancy           float64   `json:"occupancy"` // percent of rooms booked that night
+	OccupancyAdjustment float64   `json:"occupancy_adjustment"`
+	Breakfast           float64   `json:"breakfast"`
+	Rate                float64   `json:"rate"`
+}
+
+// StayQuote prices a stay in a room under a rate plan
+type StayQuote struct {
+	RatePlan      string                `json:"rate_plan"`
+	RoomID        uint                  `json:"room_id"`
+	RoomType      string                `json:"room_type"`
+	Guests        int                   `json:"guests"`
+	Nights        []NightRate           `json:"nights"`
+	RoomTotal     float64               `json:"room_total"` // excluding breakfast
+	Breakfast     float64               `json:"breakfast"`
+	Discounts     []ReservationDiscount `json:"discounts,omitempty"`
+	DiscountTotal float64               `json:"discount_total"`
+	Total         float64               `json:"total"`
+}
+
+// ratePricer prices nights under one rate plan
+type ratePricer struct {
+	plan      RatePlan
+	overrides []RateOverride
+	rules     []OccupancyRateRule
+	occupancy map[string]float64
+}
+
+// newRatePricer loads the plan, its overrides for from–to and the night
+// occupancies. Occupancy rules are loaded unless given.
+func newRatePricer(tx *gorm.DB, planCode string, from, to time.Time, rules []OccupancyRateRule) (*ratePricer, error) {
+	plan, err := FindRatePlan(tx, planCode)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &ratePricer{plan: plan, rules: rules}
+	err = tx.Where("(rate_plan_id IS NULL OR rate_plan_id = ?) AND start_date < ? AND end_date >= ?",
+		plan.ID, atHour(to, 0), atHour(from, 0)).
+		Order("id").Find(&p.overrides).Error
+	if err != nil {
+		return nil, err
+	}
+	if p.rules == nil {
+		err := tx.Where("active = ? AND (rate_plan_id IS NULL OR rate_plan_id = ?)", true, plan.ID).
+			Find(&p.rules).Error
+		if err != nil {
+			return nil, err
+		}
+	}
+	if p.occupancy, err = OccupancyByNight(tx, from, to); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// FindRatePlan loads an active rate plan by code. An empty code means BAR,
+// which prices at the room rate even if it has not been set up.
+func FindRatePlan(tx *gorm.DB, code string) (RatePlan, error) {
+	if code == "" {
+		code = RatePlanBAR
+	}
+	var plan RatePlan
+	result := tx.Where("code = ? AND active = ?", code, true).Limit(1).Find(&plan)
+	if result.Error != nil {
+		return RatePlan{}, result.Error
+	}
+	if result.RowsAffected == 0 {
+		if code == RatePlanBAR {
+			return DefaultRatePlans[0], nil
+		}
+		return RatePlan{}, fmt.Errorf("%w: %s", ErrRatePlanNotFound, code)
+	}
+	return plan, nil
+}
+
+// night prices one night of a roomType room whose own rate is roomRate
+func (p *ratePricer) night(roomType string, roomRate float64, night time.Time, guests int) NightRate {
+	night = atHour(night, 0)
+	rate := NightRate{Date: night, BaseRate: roomRate, Occupancy: p.occupancy[night.Format(dateKey)]}
+
+	var override *RateOverride
+	for i := range p.overrides {
+		o := &p.overrides[i]
+		if o.RoomType != roomType || night.Before(atHour(o.StartDate, 0)) || night.After(atHour(o.EndDate, 0)) {
+			continue
+		}
+		// plan-specific overrides beat general ones, later ones beat earlier
+		if override == nil || o.RatePlanID != nil || override.RatePlanID == nil {
+			override = o
+		}
+	}
+	if override != nil {
+		rate.BaseRate = override.NightlyRate
+	}
+
+	rate.PlanAdjustment = roundMoney(rate.BaseRate * p.plan.AdjustmentPercent / 100)
+	if p.plan.OccupancyPricing {
+		if rule := occupancyRule(p.rules, rate.Occupancy); rule != nil {
+			rate.OccupancyAdjustment = roundMoney((rate.BaseRate + rate.PlanAdjustment) * rule.AdjustmentPercent / 100)
+		}
+	}
+	if p.plan.BreakfastIncluded {
+		rate.Breakfast = roundMoney(p.plan.BreakfastPricePerGuest * float64(guests))
+	}
+	rate.Rate = roundMoney(rate.BaseRate + rate.PlanAdjustment + rate.OccupancyAdjustment + rate.Breakfast)
+	return rate
+}
+
+// occupancyRule returns the rule with the highest threshold the occupancy
+// has reached, or nil
+func occupancyRule(rules []OccupancyRateRule, occupancy float64) *OccupancyRateRule {
+	var best *OccupancyRateRule
+	for i := range rules {
+		if occupancy >= rules[i].MinOccupancy && (best == nil || rules[i].MinOccupancy > best.MinOccupancy) {
+			best = &rules[i]
+		}
+	}
+	return best
+}
+
+// OccupancyByNight returns, for each night from from up to to keyed by
+// YYYY-MM-DD, the percentage of all rooms booked, as the dashboard
+// occupancy rate counts it
+func OccupancyByNight(tx *gorm.DB, from, to time.Time) (map[string]float64, error) {
+	days, err := InventoryCalendar(tx, from, to)
+	if err != nil {
+		return nil, err
+	}
+	booked, physical := map[string]int{}, map[string]int{}
+	for _, day := range days {
+		key := day.Date.Format(dateKey)
+		booked[key] += day.Booked
+		physical[key] += day.Physical
+	}
+	occupancy := make(map[string]float64, len(booked))
+	for key, rooms := range physical {
+		if rooms > 0 {
+			occupancy[key] = math.Round(float64(booked[key])/float64(rooms)*1000) / 10
+		}
+	}
+	return occupancy, nil
+}
+
+// QuoteStay prices each night of a stay in the room under the rate plan
+func QuoteStay(tx *gorm.DB, planCode string, room Room, checkIn, checkOut time.Time, guests int) (*StayQuote, error) {
+	if !checkOut.After(checkIn) {
+		return nil, ErrInvalidStayDates
+	}
+	if guests < 1 {
+		guests = 1
+	}
+	if guests > room.Capacity {
+		return nil, fmt.Errorf("%w: room %s sleeps %d", ErrOverCapacity, room.RoomNumber, room.Capacity)
+	}
+	pricer, err := newRatePricer(tx, planCode, checkIn, checkOut, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := &StayQuote{RatePlan: pricer.plan.Code, RoomID: room.ID, RoomType: room.RoomType, Guests: guests}
+	for night := atHour(checkIn, 0); night.Before(atHour(checkOut, 0)); night = night.AddDate(0, 0, 1) {
+		rate := pricer.night(room.RoomType, room.PricePerNight, night, guests)
+		quote.Nights = append(quote.Nights, rate)
+		quote.RoomTotal += rate.Rate - rate.Breakfast
+		quote.Breakfast += rate.Breakfast
+	}
+	if len(quote.Nights) < pricer.plan.MinNights {
+		return nil, fmt.Errorf("%w: %s needs %d nights", ErrMinNights, pricer.plan.Code, pricer.plan.MinNights)
+	}
+	quote.RoomTotal = roundMoney(quote.RoomTotal)
+	quote.Breakfast = roundMoney(quote.Breakfast)
+	quote.Total = roundMoney(quote.RoomTotal + quote.Breakfast)
+	return quote, nil
+}
+
+// keepBookedRates prices each quoted night that was already booked at its
+// booked rate and re-totals the quote
+func keepBookedRates(quote *StayQuote, booked []NightRate) {
+	if len(booked) == 0 {
+		return
+	}
+	rates := make(map[string]NightRate, len(booked))
+	for _, night := range booked {
+		rates[night.Date.Format("2006-01-02")] = night
+	}
+	quote.RoomTotal, quote.Breakfast = 0, 0
+	for i, night := range quote.Nights {
+		if rate, ok := rates[night.Date.Format("2006-01-02")]; ok {
+			rate.Date = night.Date
+			quote.Nights[i] = rate
+		}
+		quote.RoomTotal += quote.Nights[i].Rate - quote.Nights[i].Breakfast
+		quote.Breakfast += quote.Nights[i].Breakfast
+	}
+	quote.RoomTotal = roundMoney(quote.RoomTotal)
+	quote.Breakfast = roundMoney(quote.Breakfast)
+	quote.Total = roundMoney(quote.RoomTotal + quote.Breakfast)
+}
+
+// PriceReservation prices the reservation for its guest under its rate plan
+// and promotion code, sets its Nights, TotalPrice and itemised Discounts,
+// and returns the quote. A use of the promotion is claimed, so it must run
+// in the transaction that saves the reservation.
+func PriceReservation(tx *gorm.DB, reservation *Reservation, guests int, now time.Time) (*StayQuote, error) {
+	var room Room
+	if err := tx.First(&room, reservation.RoomID).Error; err != nil {
+		return nil, err
+	}
+	quote, err := QuoteGuestStay(tx, StayRequest{
+		RatePlan:      reservation.RatePlan,
+		Room:          room,
+		CheckInDate:   reservation.CheckInDate,
+		CheckOutDate:  reservation.CheckOutDate,
+		Guests:        guests,
+		GuestID:       reservation.GuestID,
+		PromotionCode: reservation.PromotionCode,
+	}, now)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyQuote(tx, reservation, quote); err != nil {
+		return nil, err
+	}
+	return quote, nil
+}
+
+// applyQuote claims the quote's promotion uses and sets the reservation's
+// rate plan, Nights, TotalPrice, Discounts and NightRates from it
+func applyQuote(tx *gorm.DB, reservation *Reservation, quote *StayQuote) error {
+	if err := claimPromotions(tx, quote.Discounts); err != nil {
+		return err
+	}
+	reservation.RatePlan = quote.RatePlan
+	reservation.Nights = len(quote.Nights)
+	reservation.TotalPrice = quote.Total
+	reservation.DiscountAmount = quote.DiscountTotal
+	reservation.Discounts = quote.Discounts
+	for _, discount := range quote.Discounts {
+		if discount.PromotionID != nil {
+			reservation.PromotionCode = discount.Code
+		}
+	}
+	reservation.NightRates = make([]ReservationNight, 0, len(quote.Nights))
+	for _, night := range quote.Nights {
+		reservation.NightRates = append(reservation.NightRates, ReservationNight{
+			ReservationID: reservation.ID,
+			Date:          night.Date,
+			Rate:          night.Rate,
+			Breakfast:     night.Breakfast,
+		})
+	}
+	return nil
+}
+
+// RateCalendarDay is the rate of a room type for one night
+type RateCalendarDay struct {
+	NightRate
+	RoomType string `json:"room_type"`
+	Sellable int    `json:"sellable"`
+}
+
+// RateCalendar prices every room type under the rate plan for each night
+// from from up to, but not including, to. Each type is priced from its
+// cheapest room.
+func RateCalendar(tx *gorm.DB, planCode string, from, to time.Time, guests int) ([]RateCalendarDay, error) {
+	pricer, err := newRatePricer(tx, planCode, from, to, nil)
+	if err != nil {
+		return nil, err
+	}
+	baseRates, err := roomTypeBaseRates(tx)
+	if err != nil {
+		return nil, err
+	}
+	days, err := InventoryCalendar(tx, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	calendar := make([]RateCalendarDay, 0, len(days))
+	for _, day := range days {
+		calendar = append(calendar, RateCalendarDay{
+			NightRate: pricer.night(day.RoomType, baseRates[day.RoomType], day.Date, guests),
+			RoomType:  day.RoomType,
+			Sellable:  day.Sellable,
+		})
+	}
+	return calendar, nil
+}
+
+// roomTypeBaseRates returns the cheapest nightly rate of each room type
+func roomTypeBaseRates(tx *gorm.DB) (map[string]float64, error) {
+	var rows []struct {
+		RoomType string
+		Rate     float64
+	}
+	err := tx.Model(&Room{}).Select("room_type, MIN(price_per_night) AS rate").Group("room_type").Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	rates := make(map[string]float64, len(rows))
+	for _, row := range rows {
+		rates[row.RoomType] = row.Rate
+	}
+	return rates, nil
+}
+
+// RevenueSimulation asks what the remaining rooms would earn if occupancy
+// rose to ExpectedOccupancy under Rules. Nil Rules means the active rules.
+type RevenueSimulation struct {
+	RatePlan          string
+	From              time.Time
+	To                time.Time
+	ExpectedOccupancy float64 // percent of rooms booked by the night
+	Rules             []OccupancyRateRule
+}
+
+// SimulatedNight is the projected revenue of one night
+type SimulatedNight struct {
+	Date                  time.Time `json:"date"`
+	CurrentOccupancy      float64   `json:"current_occupancy"`
+	ExpectedOccupancy     float64   `json:"expected_occupancy"`
+	OnTheBooks            float64   `json:"on_the_books"` // revenue of reservations already made
+	PickupRooms           int       `json:"pickup_rooms"`
+	PickupRevenue         float64   `json:"pickup_revenue"`          // under the simulated rules
+	BaselinePickupRevenue float64   `json:"baseline_pickup_revenue"` // without occupancy rules
+}
+
+// RevenueSimulationResult totals a simulation
+type RevenueSimulationResult struct {
+	Nights           []SimulatedNight `json:"nights"`
+	OnTheBooks       float64          `json:"on_the_books"`
+	ProjectedRevenue float64          `json:"projected_revenue"`
+	BaselineRevenue  float64          `json:"baseline_revenue"`
+	Difference       float64          `json:"difference"`
+}
+
+// SimulateRevenue projects room revenue for each night. Reservations already
+// made earn what they were sold for. The rooms still to be sold to reach the
+// expected occupancy are sold one at a time across room types in turn, each
+// priced at the occupancy reached when it sells, once under the simulated
+// rules and once with no occupancy pricing as a baseline. Nothing is saved.
+func SimulateRevenue(tx *gorm.DB, sim RevenueSimulation) (*RevenueSimulationResult, error) {
+	pricer, err := newRatePricer(tx, sim.RatePlan, sim.From, sim.To, sim.Rules)
+	if err != nil {
+		return nil, err
+	}
+	baseline := *pricer
+	baseline.rules = []OccupancyRateRule{}
+
+	baseRates, err := roomTypeBaseRates(tx)
+	if err != nil {
+		return nil, err
+	}
+	days, err := InventoryCalendar(tx, sim.From, sim.To)
+	if err != nil {
+		return nil, err
+	}
+	onTheBooks, err := bookedRevenueByNight(tx, sim.From, sim.To)
+	if err != nil {
+		return nil, err
+	}
+
+	byNight := map[string][]InventoryDay{}
+	var nights []time.Time
+	for _, day := range days {
+		key := day.Date.Format(dateKey)
+		if _, ok := byNight[key]; !ok {
+			nights = append(nights, day.Date)
+		}
+		byNight[key] = append(byNight[key], day)
+	}
+	sort.Slice(nights, func(i, j int) bool { return nights[i].Before(nights[j]) })
+
+	result := &RevenueSimulationResult{}
+	for _, date := range nights {
+		key := date.Format(dateKey)
+		types := byNight[key]
+		physical, booked := 0, 0
+		sellable := make([]int, len(types))
+		for i, day := range types {
+			physical += day.Physical
+			booked += day.Booked
+			sellable[i] = day.Sellable
+		}
+		night := SimulatedNight{Date: date, ExpectedOccupancy: sim.ExpectedOccupancy, OnTheBooks: roundMoney(onTheBooks[key])}
+		if physical == 0 {
+			result.Nights = append(result.Nights, night)
+			continue
+		}
+		night.CurrentOccupancy = math.Round(float64(booked)/float64(physical)*1000) / 10
+		target := int(math.Ceil(sim.ExpectedOccupancy / 100 * float64(physical)))
+
+		for sold := booked; sold < target; {
+			progress := false
+			for i, day := range types {
+				if sold >= target || sellable[i] == 0 {
+					continue
+				}
+				occupancy := float64(sold) / float64(physical) * 100
+				pricer.occupancy[key], baseline.occupancy[key] = occupancy, occupancy
+				night.PickupRevenue += pricer.night(day.RoomType, baseRates[day.RoomType], date, 1).Rate
+				night.BaselinePickupRevenue += baseline.night(day.RoomType, baseRates[day.RoomType], date, 1).Rate
+				night.PickupRooms++
+				sellable[i]--
+				sold++
+				progress = true
+			}
+			if !progress {
+				break
+			}
+		}
+		night.PickupRevenue = roundMoney(night.PickupRevenue)
+		night.BaselinePickupRevenue = roundMoney(night.BaselinePickupRevenue)
+
+		result.Nights = append(result.Nights, night)
+		result.OnTheBooks += night.OnTheBooks
+		result.ProjectedRevenue += night.OnTheBooks + night.PickupRevenue
+		result.BaselineRevenue += night.OnTheBooks + night.BaselinePickupRevenue
+	}
+	result.OnTheBooks = roundMoney(result.OnTheBooks)
+	result.ProjectedRevenue = roundMoney(result.ProjectedRevenue)
+	result.BaselineRevenue = roundMoney(result.BaselineRevenue)
+	result.Difference = roundMoney(result.ProjectedRevenue - result.BaselineRevenue)
+	return result, nil
+}
+
+// bookedRevenueByNight spreads each active reservation's total evenly over
+// its nights and sums it per night from from up to to
+func bookedRevenueByNight(tx *gorm.DB, from, to time.Time) (map[string]float64, error) {
+	from, to = atHour(from, 0), atHour(to, 0)
+	var reservations []Reservation
+	err := tx.Where("status IN ? AND check_in_date < ? AND check_out_date > ?",
+		ActiveReservationStatuses, to, from).Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	revenue := map[string]float64{}
+	for _, reservation := range reservations {
+		if reservation.Nights == 0 {
+			continue
+		}
+		perNight := reservation.TotalPrice / float64(reservation.Nights)
+		for night := atHour(reservation.CheckInDate, 0); night.Before(atHour(reservation.CheckOutDate, 0)); night = night.AddDate(0, 0, 1) {
+			if !night.Before(from) && night.Before(to) {
+				revenue[night.Format(dateKey)] += perNight
+			}
+		}
+	}
+	return revenue, nil
+}
@@ -0,0 +1,372 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Reservation change types
+const (
+	ChangeTypeDates          = "date-change"
+	ChangeTypeRoom           = "room-change"
+	ChangeTypeDatesAndRoom   = "date-and-room-change"
+	ChangeTypeEarlyDeparture = "early-departure"
+	ChangeTypePrice          = "price-change"
+)
+
+// SourceReservationChange tags folio charges posted for a reservation change
+const SourceReservationChange = "reservation_change"
+
+// Reservation modification errors
+var (
+	ErrNoChange          = errors.New("modification does not change the reservation")
+	ErrInvalidStayDates  = errors.New("check-out must be after check-in, and not in the past")
+	ErrStayStarted       = errors.New("check-in date cannot change after check-in")
+	ErrReservationClosed = errors.New("reservation can no longer be modified")
+	ErrRoomUnavailable   = errors.New("room is out of service")
+)
+
+// ReservationModification is a requested change to a reservation. Nil
+// fields keep their current value.
+type ReservationModification struct {
+	CheckInDate  *time.Time
+	CheckOutDate *time.Time
+	RoomID       *uint
+	TotalPrice   *float64 // as sold by a sales channel; re-quoted if nil
+	StaffID      *uint
+	Reason       string
+}
+
+// ModifyReservation changes a reservation's dates and/or room. The new stay
+// must be free in the new room and fit its capacity. The stay is re-quoted
+// under the reservation's rate plan for the new dates and room, with its
+// discounts re-applied; nights kept stay at their booked rate, and a
+// promotion the new stay is no longer eligible for is dropped. Group stays
+// keep their nightly group rate, and a TotalPrice given by a sales channel
+// is taken as it is. The change is recorded and the price difference posted
+// to the folio, or to the master folio when the group pays for the room.
+// Nights given up are offered to the waitlist.
+//
+// Checked-in reservations may change room or check-out date only; a
+// check-out earlier than booked is an early departure.
+func ModifyReservation(db *gorm.DB, reservation *Reservation, mod ReservationModification, now time.Time) (*ReservationChange, error) {
+	var change *ReservationChange
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		change, err = modifyReservation(tx, reservation, mod, now)
+		return err
+	})
+	return change, err
+}
+
+func modifyReservation(tx *gorm.DB, reservation *Reservation, mod ReservationModification, now time.Time) (*ReservationChange, error) {
+	inHouse := reservation.Status == ReservationStatusCheckedIn
+	if !inHouse && reservation.Status != ReservationStatusPending && reservation.Status != ReservationStatusConfirmed {
+		return nil, fmt.Errorf("%w: %s", ErrReservationClosed, reservation.Status)
+	}
+
+	checkIn, checkOut, roomID := reservation.CheckInDate, reservation.CheckOutDate, reservation.RoomID
+	if mod.CheckInDate != nil {
+		checkIn = *mod.CheckInDate
+	}
+	if mod.CheckOutDate != nil {
+		checkOut = *mod.CheckOutDate
+	}
+	if mod.RoomID != nil {
+		roomID = *mod.RoomID
+	}
+	datesChanged := !checkIn.Equal(reservation.CheckInDate) || !checkOut.Equal(reservation.CheckOutDate)
+	roomChanged := roomID != reservation.RoomID
+	priceChanged := mod.TotalPrice != nil && roundMoney(*mod.TotalPrice) != reservation.TotalPrice
+	if !datesChanged && !roomChanged && !priceChanged {
+		return nil, ErrNoChange
+	}
+
+	today := atHour(now, 0)
+	if !checkOut.After(checkIn) || checkOut.Before(today) {
+		return nil, ErrInvalidStayDates
+	}
+	if inHouse && !checkIn.Equal(reservation.CheckInDate) {
+		return nil, ErrStayStarted
+	}
+	if !inHouse && !checkIn.Equal(reservation.CheckInDate) && checkIn.Before(today) {
+		return nil, ErrInvalidStayDates
+	}
+
+	var oldRoom, room Room
+	if err := tx.First(&oldRoom, reservation.RoomID).Error; err != nil {
+		return nil, err
+	}
+	room = oldRoom
+	var occupants int64
+	err := tx.Model(&ReservationOccupant{}).Where("reservation_id = ?", reservation.ID).Count(&occupants).Error
+	if err != nil {
+		return nil, err
+	}
+	if roomChanged {
+		if err := tx.First(&room, roomID).Error; err != nil {
+			return nil, err
+		}
+		if room.Status == RoomStatusMaintenance {
+			return nil, fmt.Errorf("%w: room %s", ErrRoomUnavailable, room.RoomNumber)
+		}
+		if int(occupants) > room.Capacity {
+			return nil, fmt.Errorf("%w: room %s sleeps %d", ErrOverCapacity, room.RoomNumber, room.Capacity)
+		}
+	}
+
+	free, err := RoomIsFree(tx, room.ID, checkIn, checkOut, reservation.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !free {
+		return nil, fmt.Errorf("%w: room %s", ErrRoomNoLongerFree, room.RoomNumber)
+	}
+
+	nights := int(math.Round(atHour(checkOut, 0).Sub(atHour(checkIn, 0)).Hours() / 24))
+	var quote *StayQuote
+	var total float64
+	if mod.TotalPrice != nil {
+		total = roundMoney(*mod.TotalPrice)
+	} else if reservation.GroupBookingID != nil {
+		rate := oldRoom.PricePerNight
+		if reservation.Nights > 0 {
+			rate = reservation.TotalPrice / float64(reservation.Nights)
+		}
+		total = roundMoney(rate * float64(nights))
+	} else {
+		// The reservation's promotion use is given back before re-quoting so
+		// that it is not counted against the usage limit twice
+		if err := releasePromotions(tx, reservation.ID); err != nil {
+			return nil, err
+		}
+		err = tx.Where("reservation_id = ?", reservation.ID).Delete(&ReservationDiscount{}).Error
+		if err != nil {
+			return nil, err
+		}
+		booked, err := bookedNights(tx, reservation)
+		if err != nil {
+			return nil, err
+		}
+		req := StayRequest{
+			RatePlan:      reservation.RatePlan,
+			Room:          room,
+			CheckInDate:   checkIn,
+			CheckOutDate:  checkOut,
+			Guests:        int(occupants),
+			GuestID:       reservation.GuestID,
+			PromotionCode: reservation.PromotionCode,
+			BookedAt:      reservation.CreatedAt,
+			BookedNights:  booked,
+		}
+		quote, err = QuoteGuestStay(tx, req, now)
+		if req.PromotionCode != "" && promotionLost(err) {
+			req.PromotionCode = ""
+			reservation.PromotionCode = ""
+			quote, err = QuoteGuestStay(tx, req, now)
+		}
+		if err != nil {
+			return nil, err
+		}
+		total = quote.Total
+	}
+
+	change := &ReservationChange{
+		ReservationID:   reservation.ID,
+		ChangeType:      changeType(inHouse, datesChanged, roomChanged, checkOut.Before(reservation.CheckOutDate)),
+		OldRoomID:       reservation.RoomID,
+		NewRoomID:       room.ID,
+		OldCheckInDate:  reservation.CheckInDate,
+		NewCheckInDate:  checkIn,
+		OldCheckOutDate: reservation.CheckOutDate,
+		NewCheckOutDate: checkOut,
+		OldNights:       reservation.Nights,
+		NewNights:       nights,
+		OldTotalPrice:   reservation.TotalPrice,
+		NewTotalPrice:   total,
+		PriceDifference: roundMoney(total - reservation.TotalPrice),
+		ChangedByID:     mod.StaffID,
+		Reason:          mod.Reason,
+		ChangedAt:       now,
+	}
+	if err := tx.Create(change).Error; err != nil {
+		return nil, err
+	}
+
+	if change.PriceDifference != 0 {
+		description := fmt.Sprintf("Reservation %s %s: %d to %d nights", reservation.BookingID, change.ChangeType, change.OldNights, nights)
+		group, err := masterPaidGroup(tx, reservation)
+		if err != nil {
+			return nil, err
+		}
+		if group != nil {
+			err = tx.Create(&GroupFolioCharge{
+				GroupBookingID: group.ID,
+				ReservationID:  &reservation.ID,
+				ChargeType:     FolioChargeRoom,
+				Description:    description,
+				Amount:         change.PriceDifference,
+				SourceType:     SourceReservationChange,
+				SourceID:       change.ID,
+				PostedAt:       now,
+			}).Error
+		} else {
+			err = tx.Create(&FolioCharge{
+				ReservationID: reservation.ID,
+				GuestID:       reservation.GuestID,
+				ChargeType:    FolioChargeRoom,
+				Description:   description,
+				Amount:        change.PriceDifference,
+				SourceType:    SourceReservationChange,
+				SourceID:      change.ID,
+				PostedAt:      now,
+			}).Error
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Night rates are stored again only for a quoted stay; after a group or
+	// channel price the next change falls back to the average nightly rate
+	err = tx.Where("reservation_id = ?", reservation.ID).Delete(&ReservationNight{}).Error
+	if err != nil {
+		return nil, err
+	}
+	reservation.NightRates = nil
+	updates := map[string]interface{}{}
+	if quote != nil {
+		if err := applyQuote(tx, reservation, quote); err != nil {
+			return nil, err
+		}
+		for i := range reservation.Discounts {
+			reservation.Discounts[i].ReservationID = reservation.ID
+		}
+		if len(reservation.Discounts) > 0 {
+			if err := tx.Create(&reservation.Discounts).Error; err != nil {
+				return nil, err
+			}
+		}
+		if err := tx.Create(&reservation.NightRates).Error; err != nil {
+			return nil, err
+		}
+		updates["rate_plan"] = reservation.RatePlan
+		updates["discount_amount"] = reservation.DiscountAmount
+		updates["promotion_code"] = reservation.PromotionCode
+	}
+	reservation.RoomID = room.ID
+	reservation.CheckInDate = checkIn
+	reservation.CheckOutDate = checkOut
+	reservation.Nights = nights
+	reservation.TotalPrice = total
+	updates["room_id"] = room.ID
+	updates["check_in_date"] = checkIn
+	updates["check_out_date"] = checkOut
+	updates["nights"] = nights
+	updates["total_price"] = total
+	err = tx.Model(reservation).Updates(updates).Error
+	if err != nil {
+		return nil, err
+	}
+
+	if inHouse && roomChanged {
+		if err := moveInHouseGuest(tx, reservation.ID, oldRoom.ID, room.ID); err != nil {
+			return nil, err
+		}
+	}
+	err = matchReleasedStay(tx, change.OldRoomID, change.OldCheckInDate, change.OldCheckOutDate, now)
+	if err != nil {
+		return nil, err
+	}
+	return change, nil
+}
+
+// bookedNights returns the rates the reservation's nights were booked at.
+// A reservation priced without a quote, by a group or a sales channel, has
+// none stored and keeps its average nightly rate before discounts.
+func bookedNights(tx *gorm.DB, reservation *Reservation) ([]NightRate, error) {
+	var stored []ReservationNight
+	err := tx.Where("reservation_id = ?", reservation.ID).Order("date").Find(&stored).Error
+	if err != nil {
+		return nil, err
+	}
+	booked := make([]NightRate, 0, len(stored))
+	for _, night := range stored {
+		booked = append(booked, NightRate{
+			Date:      night.Date,
+			BaseRate:  night.Rate - night.Breakfast,
+			Breakfast: night.Breakfast,
+			Rate:      night.Rate,
+		})
+	}
+	if len(booked) > 0 || reservation.Nights == 0 {
+		return booked, nil
+	}
+	rate := roundMoney((reservation.TotalPrice + reservation.DiscountAmount) / float64(reservation.Nights))
+	for night := atHour(reservation.CheckInDate, 0); night.Before(atHour(reservation.CheckOutDate, 0)); night = night.AddDate(0, 0, 1) {
+		booked = append(booked, NightRate{Date: night, BaseRate: rate, Rate: rate})
+	}
+	return booked, nil
+}
+
+// moveInHouseGuest moves a checked-in reservation's check-in record to the
+// new room, marks it occupied and sends the old room for cleaning, unless it
+// has been taken out of service
+func moveInHouseGuest(tx *gorm.DB, reservationID, fromRoomID, toRoomID uint) error {
+	err := tx.Model(&CheckIn{}).Where("reservation_id = ?", reservationID).Update("room_id", toRoomID).Error
+	if err != nil {
+		return err
+	}
+	if err := tx.Model(&Room{}).Where("id = ?", toRoomID).Update("status", RoomStatusOccupied).Error; err != nil {
+		return err
+	}
+	return tx.Model(&Room{}).Where("id = ? AND status = ?", fromRoomID, RoomStatusOccupied).
+		Update("status", RoomStatusCleaning).Error
+}
+
+// recordRoomMove records a move to another room with unchanged dates and
+// price, so the stay's calendar event is revised in both rooms' feeds
+func recordRoomMove(tx *gorm.DB, reservation *Reservation, toRoomID uint, reason string, now time.Time) error {
+	return tx.Create(&ReservationChange{
+		ReservationID:   reservation.ID,
+		ChangeType:      ChangeTypeRoom,
+		OldRoomID:       reservation.RoomID,
+		NewRoomID:       toRoomID,
+		OldCheckInDate:  reservation.CheckInDate,
+		NewCheckInDate:  reservation.CheckInDate,
+		OldCheckOutDate: reservation.CheckOutDate,
+		NewCheckOutDate: reservation.CheckOutDate,
+		OldNights:       reservation.Nights,
+		NewNights:       reservation.Nights,
+		OldTotalPrice:   reservation.TotalPrice,
+		NewTotalPrice:   reservation.TotalPrice,
+		Reason:          reason,
+		ChangedAt:       now,
+	}).Error
+}
+
+// promotionLost reports whether a re-quote failed only because the
+// reservation's promotion no longer applies to the stay
+func promotionLost(err error) bool {
+	return errors.Is(err, ErrPromotionNotFound) || errors.Is(err, ErrPromotionNotValid) ||
+		errors.Is(err, ErrPromotionNotEligible) || errors.Is(err, ErrPromotionUsedUp) ||
+		errors.Is(err, ErrPromotionNotCombinable)
+}
+
+func changeType(inHouse, datesChanged, roomChanged, shortened bool) string {
+	switch {
+	case !datesChanged && !roomChanged:
+		return ChangeTypePrice
+	case inHouse && shortened && !roomChanged:
+		return ChangeTypeEarlyDeparture
+	case datesChanged && roomChanged:
+		return ChangeTypeDatesAndRoom
+	case roomChanged:
+		return ChangeTypeRoom
+	}
+	return ChangeTypeDates
+}
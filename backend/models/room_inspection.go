@@ -0,0 +1,216 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Inspection results
+const (
+	InspectionPassed = "passed"
+	InspectionFailed = "failed"
+)
+
+// DefaultInspectionPassScore is used when a checklist has no pass score set
+const DefaultInspectionPassScore = 80
+
+// Room inspection errors
+var (
+	ErrNoInspectionChecklist = errors.New("no inspection checklist for room type")
+	ErrIncompleteInspection  = errors.New("inspection does not cover every checklist item")
+)
+
+// RecordRoomInspection scores a supervisor's inspection of a cleaned room
+// against the checklist for its room type. Only ChecklistItemID, Passed and
+// Notes are read from the inspection's results.
+//
+// A passed inspection completes the housekeeping task and releases the room.
+// A failed one marks the task failed and re-queues a housekeeping request so
+// the same housekeeper re-cleans the room; the room stays in "cleaning".
+func RecordRoomInspection(tx *gorm.DB, task *HousekeepingTask, inspection *RoomInspection, now time.Time) error {
+	if task.Status != TaskStatusCleaned {
+		return fmt.Errorf("%w: cannot inspect %s task", ErrInvalidStatusTransition, task.Status)
+	}
+
+	var room Room
+	if err := tx.First(&room, task.RoomID).Error; err != nil {
+		return err
+	}
+
+	var checklist InspectionChecklist
+	result := tx.Preload("Items").Where("room_type = ? AND active = ?", room.RoomType, true).Limit(1).Find(&checklist)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%w: %s", ErrNoInspectionChecklist, room.RoomType)
+	}
+
+	outcomes := make(map[uint]RoomInspectionResult, len(inspection.Results))
+	for _, r := range inspection.Results {
+		outcomes[r.ChecklistItemID] = r
+	}
+
+	var results []RoomInspectionResult
+	var failedLabels []string
+	totalWeight, passedWeight := 0, 0
+	criticalFailed := false
+	for _, item := range checklist.Items {
+		outcome, ok := outcomes[item.ID]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrIncompleteInspection, item.Label)
+		}
+		weight := item.Weight
+		if weight < 1 {
+			weight = 1
+		}
+		totalWeight += weight
+		if outcome.Passed {
+			passedWeight += weight
+		} else {
+			failedLabels = append(failedLabels, item.Label)
+			if item.Critical {
+				criticalFailed = true
+			}
+		}
+		results = append(results, RoomInspectionResult{
+			ChecklistItemID: item.ID,
+			Label:           item.Label,
+			Weight:          weight,
+			Critical:        item.Critical,
+			Passed:          outcome.Passed,
+			Notes:           outcome.Notes,
+		})
+	}
+
+	score := 100.0
+	if totalWeight > 0 {
+		score = math.Round(float64(passedWeight)/float64(totalWeight)*1000) / 10
+	}
+	passScore := checklist.PassScore
+	if passScore <= 0 {
+		passScore = DefaultInspectionPassScore
+	}
+
+	inspection.RoomID = room.ID
+	inspection.HousekeepingTaskID = task.ID
+	inspection.ChecklistID = checklist.ID
+	inspection.HousekeeperID = task.AssignedStaffID
+	inspection.Score = score
+	inspection.InspectedAt = now
+	inspection.Results = results
+	inspection.Result = InspectionPassed
+	if criticalFailed || score < passScore {
+		inspection.Result = InspectionFailed
+	}
+	if err := tx.Create(inspection).Error; err != nil {
+		return err
+	}
+
+	inspectorID := inspection.InspectorID
+	if inspection.Result == InspectionPassed {
+		return advanceHousekeepingTask(tx, task, TaskStatusInspected, &inspectorID, now)
+	}
+
+	if err := advanceHousekeepingTask(tx, task, TaskStatusFailed, &inspectorID, now); err != nil {
+		return err
+	}
+	return requeueFailedInspection(tx, task, inspection, failedLabels, now)
+}
+
+// requeueFailedInspection raises a housekeeping request for a room that
+// failed inspection and schedules it as an immediate re-clean for the
+// housekeeper who cleaned it.
+func requeueFailedInspection(tx *gorm.DB, task *HousekeepingTask, inspection *RoomInspection, failedLabels []string, now time.Time) error {
+	description := "Re-clean after failed inspection: " + strings.Join(failedLabels, ", ")
+
+	var reClean *HousekeepingTask
+	if task.ReservationID != nil {
+		var reservation Reservation
+		if err := tx.First(&reservation, *task.ReservationID).Error; err != nil {
+			return err
+		}
+		req := HousekeepingRequest{
+			ReservationID: reservation.ID,
+			GuestID:       reservation.GuestID,
+			RequestType:   "cleaning",
+			Description:   description,
+			ScheduleTime:  "immediate",
+			Status:        RequestStatusPending,
+			RequestedAt:   now,
+		}
+		if err := tx.Create(&req).Error; err != nil {
+			return err
+		}
+		created, err := ScheduleHousekeepingRequest(tx, &req, now)
+		if err != nil {
+			return err
+		}
+		reClean = created
+
+		inspection.RequeuedRequestID = &req.ID
+		if err := tx.Model(inspection).Update("requeued_request_id", req.ID).Error; err != nil {
+			return err
+		}
+	} else {
+		start, end := HousekeepingWindow("immediate", now, now)
+		reClean = &HousekeepingTask{
+			RoomID:      task.RoomID,
+			Status:      TaskStatusPending,
+			TaskDate:    atHour(start, 0),
+			WindowStart: start,
+			WindowEnd:   end,
+			Floor:       task.Floor,
+			Notes:       description,
+		}
+		if err := tx.Create(reClean).Error; err != nil {
+			return err
+		}
+	}
+
+	updates := map[string]interface{}{
+		"task_type":         TaskTypeReClean,
+		"estimated_minutes": housekeepingTaskMinutes[TaskTypeReClean],
+	}
+	if err := tx.Model(reClean).Updates(updates).Error; err != nil {
+		return err
+	}
+	if task.AssignedStaffID == nil {
+		return nil
+	}
+	// A housekeeper who has gone off shift leaves the re-clean for the
+	// next assignment run.
+	onDuty, err := IsOnDuty(tx, *task.AssignedStaffID)
+	if err != nil || !onDuty {
+		return err
+	}
+	return AssignHousekeepingTask(tx, reClean, *task.AssignedStaffID)
+}
+
+// HousekeeperInspectionScore summarises the inspections of one housekeeper's rooms
+type HousekeeperInspectionScore struct {
+	HousekeeperID uint    `json:"housekeeper_id"`
+	Inspections   int64   `json:"inspections"`
+	Passed        int64   `json:"passed"`
+	AverageScore  float64 `json:"average_score"`
+}
+
+// HousekeeperInspectionScores reports inspection counts, passes and average
+// score per housekeeper for inspections between from and to.
+func HousekeeperInspectionScores(tx *gorm.DB, from, to time.Time) ([]HousekeeperInspectionScore, error) {
+	var scores []HousekeeperInspectionScore
+	err := tx.Model(&RoomInspection{}).
+		Select("housekeeper_id, COUNT(*) AS inspections, "+
+			"SUM(CASE WHEN result = ? THEN 1 ELSE 0 END) AS passed, "+
+			"AVG(score) AS average_score", InspectionPassed).
+		Where("housekeeper_id IS NOT NULL AND inspected_at >= ? AND inspected_at < ?", from, to).
+		Group("housekeeper_id").
+		Order("average_score DESC").
+		Scan(&scores).Error
+	return scores, err
+}
@@ -0,0 +1,256 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Room move proposal statuses
+const (
+	MoveStatusProposed      = "proposed"
+	MoveStatusAccepted      = "accepted"
+	MoveStatusRejected      = "rejected"
+	MoveStatusNoAlternative = "no-alternative"
+	MoveStatusWithdrawn     = "withdrawn"
+)
+
+// OutOfOrderIssueTypes are the issue types that make a room unsellable when
+// reported at high priority
+var OutOfOrderIssueTypes = map[string]bool{
+	"ac":        true,
+	"plumbing":  true,
+	"door-lock": true,
+}
+
+// ErrRoomNoLongerFree is returned when accepting a move to a room that has
+// been booked since the move was proposed
+var ErrRoomNoLongerFree = errors.New("room is no longer free for the stay")
+
+// ShouldTakeRoomOutOfOrder reports whether an issue qualifies for putting its
+// room into maintenance automatically
+func ShouldTakeRoomOutOfOrder(issue MaintenanceIssue) bool {
+	return issue.Priority == "high" && OutOfOrderIssueTypes[issue.IssueType]
+}
+
+// CreateMaintenanceIssue stores a new issue against the reservation's room
+// with its SLA deadlines. The room is taken out of order when outOfOrder is
+// true, or when it is nil and the issue qualifies by type and priority; the
+// resulting room move proposals are returned.
+func CreateMaintenanceIssue(db *gorm.DB, issue *MaintenanceIssue, outOfOrder *bool, now time.Time) ([]RoomMoveProposal, error) {
+	var proposals []RoomMoveProposal
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var res Reservation
+		if err := tx.First(&res, issue.ReservationID).Error; err != nil {
+			return err
+		}
+		issue.RoomID = res.RoomID
+		if err := MatchIssueAsset(tx, issue); err != nil {
+			return err
+		}
+		if issue.Status == "" {
+			issue.Status = IssueStatusReported
+		}
+		if issue.ReportedAt.IsZero() {
+			issue.ReportedAt = now
+		}
+		if err := ApplyMaintenanceSLA(tx, issue); err != nil {
+			return err
+		}
+		if err := tx.Create(issue).Error; err != nil {
+			return err
+		}
+
+		take := ShouldTakeRoomOutOfOrder(*issue)
+		if outOfOrder != nil {
+			take = *outOfOrder
+		}
+		if !take {
+			return nil
+		}
+		var err error
+		proposals, err = TakeRoomOutOfOrder(tx, issue, now)
+		return err
+	})
+	return proposals, err
+}
+
+// TakeRoomOutOfOrder puts the issue's room into maintenance and proposes a
+// move for every active reservation that would use the room before the
+// issue's resolve deadline, including the guest currently staying in it.
+func TakeRoomOutOfOrder(tx *gorm.DB, issue *MaintenanceIssue, now time.Time) ([]RoomMoveProposal, error) {
+	var room Room
+	if err := tx.First(&room, issue.RoomID).Error; err != nil {
+		return nil, err
+	}
+
+	if !issue.RoomOutOfOrder {
+		previous := room.Status
+		if previous == RoomStatusMaintenance {
+			// Another issue already holds the room; keep what it will restore.
+			previous = ""
+		}
+		err := tx.Model(issue).Updates(map[string]interface{}{
+			"room_out_of_order":    true,
+			"previous_room_status": previous,
+		}).Error
+		if err != nil {
+			return nil, err
+		}
+		issue.RoomOutOfOrder = true
+		issue.PreviousRoomStatus = previous
+
+		if err := tx.Model(&room).Update("status", RoomStatusMaintenance).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	until := now.AddDate(0, 0, 1)
+	if issue.ResolveDueAt != nil && issue.ResolveDueAt.After(until) {
+		until = *issue.ResolveDueAt
+	}
+
+	var affected []Reservation
+	err := tx.Where("room_id = ? AND overbooked = ? AND status IN ? AND check_out_date > ? AND check_in_date < ?",
+		room.ID, false, ActiveReservationStatuses, now, until).
+		Order("check_in_date").Find(&affected).Error
+	if err != nil {
+		return nil, err
+	}
+
+	claimed := make(map[uint]bool)
+	var proposals []RoomMoveProposal
+	for _, res := range affected {
+		checkIn := res.CheckInDate
+		if res.Status == ReservationStatusCheckedIn {
+			checkIn = now
+		}
+		target, err := FindEquivalentRoom(tx, room, checkIn, res.CheckOutDate, res.ID, claimed)
+		if err != nil {
+			return nil, err
+		}
+
+		proposal := RoomMoveProposal{
+			MaintenanceIssueID: issue.ID,
+			ReservationID:      res.ID,
+			FromRoomID:         room.ID,
+			Status:             MoveStatusNoAlternative,
+		}
+		if target != nil {
+			proposal.ToRoomID = &target.ID
+			proposal.Status = MoveStatusProposed
+			claimed[target.ID] = true
+		}
+		if err := tx.Create(&proposal).Error; err != nil {
+			return nil, err
+		}
+		proposals = append(proposals, proposal)
+	}
+	return proposals, nil
+}
+
+// AcceptRoomMove moves the reservation to the proposed room. A guest who is
+// already checked in has their check-in moved and takes the new room over as
+// occupied.
+func AcceptRoomMove(tx *gorm.DB, proposal *RoomMoveProposal, now time.Time) error {
+	if proposal.Status != MoveStatusProposed || proposal.ToRoomID == nil {
+		return fmt.Errorf("%w: cannot accept %s move", ErrInvalidStatusTransition, proposal.Status)
+	}
+
+	var res Reservation
+	if err := tx.First(&res, proposal.ReservationID).Error; err != nil {
+		return err
+	}
+	checkIn := res.CheckInDate
+	if res.Status == ReservationStatusCheckedIn {
+		checkIn = now
+	}
+	free, err := RoomIsFree(tx, *proposal.ToRoomID, checkIn, res.CheckOutDate, res.ID)
+	if err != nil {
+		return err
+	}
+	if !free {
+		return ErrRoomNoLongerFree
+	}
+
+	if err := recordRoomMove(tx, &res, *proposal.ToRoomID, "Room out of order", now); err != nil {
+		return err
+	}
+	if err := tx.Model(&res).Update("room_id", *proposal.ToRoomID).Error; err != nil {
+		return err
+	}
+	if res.Status == ReservationStatusCheckedIn {
+		if err := moveInHouseGuest(tx, res.ID, proposal.FromRoomID, *proposal.ToRoomID); err != nil {
+			return err
+		}
+	}
+
+	proposal.Status = MoveStatusAccepted
+	proposal.DecidedAt = &now
+	return tx.Model(proposal).Updates(map[string]interface{}{
+		"status":     MoveStatusAccepted,
+		"decided_at": now,
+	}).Error
+}
+
+// RejectRoomMove records that the guest or front desk declined the move
+func RejectRoomMove(tx *gorm.DB, proposal *RoomMoveProposal, now time.Time) error {
+	if proposal.Status != MoveStatusProposed {
+		return fmt.Errorf("%w: cannot reject %s move", ErrInvalidStatusTransition, proposal.Status)
+	}
+	proposal.Status = MoveStatusRejected
+	proposal.DecidedAt = &now
+	return tx.Model(proposal).Updates(map[string]interface{}{
+		"status":     MoveStatusRejected,
+		"decided_at": now,
+	}).Error
+}
+
+// RestoreRoomAfterMaintenance puts a room taken out of order by the issue
+// back into service, unless another open issue is still holding it. Moves
+// that were never acted on are withdrawn.
+func RestoreRoomAfterMaintenance(tx *gorm.DB, issue *MaintenanceIssue, now time.Time) error {
+	if !issue.RoomOutOfOrder {
+		return nil
+	}
+
+	err := tx.Model(&RoomMoveProposal{}).
+		Where("maintenance_issue_id = ? AND status = ?", issue.ID, MoveStatusProposed).
+		Updates(map[string]interface{}{"status": MoveStatusWithdrawn, "decided_at": now}).Error
+	if err != nil {
+		return err
+	}
+
+	var holding int64
+	err = tx.Model(&MaintenanceIssue{}).
+		Where("room_id = ? AND id <> ? AND room_out_of_order = ? AND status <> ?",
+			issue.RoomID, issue.ID, true, IssueStatusResolved).
+		Count(&holding).Error
+	if err != nil {
+		return err
+	}
+	if holding > 0 {
+		return nil
+	}
+
+	var occupied int64
+	err = tx.Model(&Reservation{}).
+		Where("room_id = ? AND status = ?", issue.RoomID, ReservationStatusCheckedIn).
+		Count(&occupied).Error
+	if err != nil {
+		return err
+	}
+
+	status := issue.PreviousRoomStatus
+	switch {
+	case occupied > 0:
+		status = RoomStatusOccupied
+	case status == "" || status == RoomStatusOccupied:
+		status = RoomStatusAvailable
+	}
+	return tx.Model(&Room{}).
+		Where("id = ? AND status = ?", issue.RoomID, RoomStatusMaintenance).
+		Update("status", status).Error
+}
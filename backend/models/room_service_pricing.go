@@ -0,0 +1,147 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Folio charge types
+const (
+	FolioChargeRoom        = "room"
+	FolioChargeRoomService = "room-service"
+	FolioChargeAdjustment  = "adjustment"
+)
+
+// SourceRoomServiceOrder tags folio charges and stock movements created by a room service order
+const SourceRoomServiceOrder = "room_service_order"
+
+// Room service pricing errors
+var (
+	ErrEmptyOrder          = errors.New("order has no items")
+	ErrInvalidQuantity     = errors.New("quantity must be at least 1")
+	ErrMenuItemNotFound    = errors.New("menu item not found")
+	ErrMenuItemUnavailable = errors.New("menu item not available")
+)
+
+// RoomServicePricing holds the fees applied on top of the menu subtotal
+type RoomServicePricing struct {
+	DeliveryFee       float64 // flat fee per order
+	ServiceChargeRate float64 // fraction of subtotal, e.g. 0.10
+	VATRate           float64 // fraction of subtotal + delivery + service charge, e.g. 0.075
+}
+
+// DefaultRoomServicePricing is used when no pricing is configured
+var DefaultRoomServicePricing = RoomServicePricing{
+	DeliveryFee:       0,
+	ServiceChargeRate: 0.10,
+	VATRate:           0.075,
+}
+
+// PriceRoomServiceOrder loads the MenuItem behind every line of the order,
+// snapshots its name and price onto the line and computes the order totals.
+// Only MenuItemID and Quantity are read from the lines; anything else the
+// caller set is overwritten.
+func PriceRoomServiceOrder(tx *gorm.DB, order *RoomServiceOrder, pricing RoomServicePricing) error {
+	if len(order.Lines) == 0 {
+		return ErrEmptyOrder
+	}
+
+	ids := make([]uint, 0, len(order.Lines))
+	for _, line := range order.Lines {
+		if line.Quantity < 1 {
+			return ErrInvalidQuantity
+		}
+		ids = append(ids, line.MenuItemID)
+	}
+
+	var items []MenuItem
+	if err := tx.Where("id IN ?", ids).Find(&items).Error; err != nil {
+		return err
+	}
+	byID := make(map[uint]MenuItem, len(items))
+	for _, item := range items {
+		byID[item.ID] = item
+	}
+
+	subtotal := 0.0
+	for i := range order.Lines {
+		line := &order.Lines[i]
+		item, ok := byID[line.MenuItemID]
+		if !ok {
+			return fmt.Errorf("%w: %d", ErrMenuItemNotFound, line.MenuItemID)
+		}
+		if !item.Sellable() {
+			return fmt.Errorf("%w: %s", ErrMenuItemUnavailable, item.Name)
+		}
+		line.Name = item.Name
+		line.Category = item.Category
+		line.UnitPrice = item.Price
+		line.LineTotal = roundMoney(item.Price * float64(line.Quantity))
+		subtotal += line.LineTotal
+	}
+
+	order.Subtotal = roundMoney(subtotal)
+	order.DeliveryFee = roundMoney(pricing.DeliveryFee)
+	order.ServiceCharge = roundMoney(order.Subtotal * pricing.ServiceChargeRate)
+	order.VAT = roundMoney((order.Subtotal + order.DeliveryFee + order.ServiceCharge) * pricing.VATRate)
+	order.Total = roundMoney(order.Subtotal + order.DeliveryFee + order.ServiceCharge + order.VAT)
+	return nil
+}
+
+// CreateRoomServiceOrder prices the order, stores it with its lines, sends it
+// to the kitchen queue and posts the total to the reservation's folio in a
+// single transaction. An order without an OrderID gets one from
+// OrderIDScheme.
+func CreateRoomServiceOrder(db *gorm.DB, order *RoomServiceOrder, pricing RoomServicePricing) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := PriceRoomServiceOrder(tx, order, pricing); err != nil {
+			return err
+		}
+
+		if order.Status == "" {
+			order.Status = OrderStatusPending
+		}
+		if order.OrderedAt.IsZero() {
+			order.OrderedAt = time.Now()
+		}
+		var err error
+		if order.OrderID == "" {
+			err = InsertWithGeneratedID(tx, OrderIDScheme, order.OrderedAt, func(id string) { order.OrderID = id }, order)
+		} else {
+			err = tx.Create(order).Error
+		}
+		if err != nil {
+			return err
+		}
+		if err := QueueRoomServiceOrder(tx, order, order.OrderedAt); err != nil {
+			return err
+		}
+
+		charge := FolioCharge{
+			ReservationID: order.ReservationID,
+			GuestID:       order.GuestID,
+			ChargeType:    FolioChargeRoomService,
+			Description:   "Room service order " + order.OrderID,
+			Amount:        order.Total,
+			SourceType:    SourceRoomServiceOrder,
+			SourceID:      order.ID,
+			PostedAt:      order.OrderedAt,
+		}
+		return tx.Create(&charge).Error
+	})
+}
+
+// Sellable reports whether the item can be ordered: switched on by the
+// manager and not out of stock
+func (m MenuItem) Sellable() bool {
+	return m.Available && !m.OutOfStock
+}
+
+// roundMoney rounds an amount to two decimal places
+func roundMoney(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,310 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Errors returned when assigning work to staff
+var (
+	ErrStaffInactive     = errors.New("staff member is not active")
+	ErrStaffRoleMismatch = errors.New("staff role cannot handle this request")
+)
+
+// Kinds of assignable work
+const (
+	WorkTypeServiceRequest      = "service-request"
+	WorkTypeHousekeepingRequest = "housekeeping-request"
+	WorkTypeMaintenanceIssue    = "maintenance-issue"
+)
+
+// serviceTypeRoles is the staff role that handles each service request type.
+// Types not listed are handled by the front desk.
+var serviceTypeRoles = map[string]string{
+	"room-service": StaffRoleRoomService,
+	"housekeeping": StaffRoleHousekeeping,
+	"maintenance":  StaffRoleMaintenance,
+}
+
+// ServiceRequestRole returns the staff role that handles a service type
+func ServiceRequestRole(serviceType string) string {
+	if role, ok := serviceTypeRoles[serviceType]; ok {
+		return role
+	}
+	return StaffRoleFrontDesk
+}
+
+// findAssignableStaff loads an active staff member whose role is role, or
+// a manager, who may take any request.
+func findAssignableStaff(tx *gorm.DB, staffID uint, role string) (*Staff, error) {
+	var staff Staff
+	if err := tx.First(&staff, staffID).Error; err != nil {
+		return nil, err
+	}
+	if staff.Status != StaffStatusActive {
+		return nil, fmt.Errorf("%w: %s", ErrStaffInactive, staff.Name)
+	}
+	if staff.Role != role && staff.Role != StaffRoleManager {
+		return nil, fmt.Errorf("%w: %s is %s, needs %s", ErrStaffRoleMismatch, staff.Name, staff.Role, role)
+	}
+	return &staff, nil
+}
+
+// AssignServiceRequest assigns a service request to a staff member whose role
+// matches its service type
+func AssignServiceRequest(tx *gorm.DB, req *ServiceRequest, staffID uint) error {
+	staff, err := findAssignableStaff(tx, staffID, ServiceRequestRole(req.ServiceType))
+	if err != nil {
+		return err
+	}
+	req.AssignedStaffID = &staff.ID
+	req.AssignedTo = staff.Name
+	return tx.Model(req).Updates(map[string]interface{}{
+		"assigned_staff_id": staff.ID,
+		"assigned_to":       staff.Name,
+	}).Error
+}
+
+// AssignHousekeepingRequest assigns a housekeeping request to a housekeeper.
+// A task already scheduled for the request that has not started moves to
+// the same housekeeper.
+func AssignHousekeepingRequest(tx *gorm.DB, req *HousekeepingRequest, staffID uint) error {
+	staff, err := findAssignableStaff(tx, staffID, StaffRoleHousekeeping)
+	if err != nil {
+		return err
+	}
+	req.AssignedStaffID = &staff.ID
+	req.AssignedTo = staff.Name
+	err = tx.Model(req).Updates(map[string]interface{}{
+		"assigned_staff_id": staff.ID,
+		"assigned_to":       staff.Name,
+	}).Error
+	if err != nil || req.TaskID == nil {
+		return err
+	}
+
+	var task HousekeepingTask
+	if err := tx.First(&task, *req.TaskID).Error; err != nil {
+		return err
+	}
+	if task.Status != TaskStatusPending && task.Status != TaskStatusAssigned {
+		return nil
+	}
+	return AssignHousekeepingTask(tx, &task, staff.ID)
+}
+
+// AssignMaintenanceIssue assigns a maintenance issue to maintenance staff
+func AssignMaintenanceIssue(tx *gorm.DB, issue *MaintenanceIssue, staffID uint) error {
+	staff, err := findAssignableStaff(tx, staffID, StaffRoleMaintenance)
+	if err != nil {
+		return err
+	}
+	issue.AssignedStaffID = &staff.ID
+	issue.AssignedTo = staff.Name
+	return tx.Model(issue).Updates(map[string]interface{}{
+		"assigned_staff_id": staff.ID,
+		"assigned_to":       staff.Name,
+	}).Error
+}
+
+// AssigneeMigrationResult reports how the legacy AssignedTo strings of one
+// request table were matched to staff
+type AssigneeMigrationResult struct {
+	Table     string   `json:"table"`
+	Matched   int64    `json:"matched"`
+	Unmatched []string `json:"unmatched"` // distinct strings left for manual assignment
+}
+
+// MigrateAssigneesToStaff fills AssignedStaffID on service requests,
+// housekeeping requests and maintenance issues from their AssignedTo
+// strings, matching staff email or name without regard to case or
+// surrounding spaces. Names shared by several staff are not matched. Rows
+// already linked are left alone, so it is safe to run more than once.
+func MigrateAssigneesToStaff(tx *gorm.DB) ([]AssigneeMigrationResult, error) {
+	var staff []Staff
+	if err := tx.Find(&staff).Error; err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]uint)
+	ambiguous := make(map[string]bool)
+	for _, member := range staff {
+		for _, key := range []string{normaliseAssignee(member.Email), normaliseAssignee(member.Name)} {
+			if key == "" {
+				continue
+			}
+			if id, ok := byKey[key]; ok && id != member.ID {
+				ambiguous[key] = true
+			}
+			byKey[key] = member.ID
+		}
+	}
+	for key := range ambiguous {
+		delete(byKey, key)
+	}
+
+	var results []AssigneeMigrationResult
+	for _, model := range []interface{}{&ServiceRequest{}, &HousekeepingRequest{}, &MaintenanceIssue{}} {
+		stmt := &gorm.Statement{DB: tx}
+		if err := stmt.Parse(model); err != nil {
+			return nil, err
+		}
+		result := AssigneeMigrationResult{Table: stmt.Schema.Table, Unmatched: []string{}}
+
+		var assignees []string
+		err := tx.Model(model).
+			Where("assigned_staff_id IS NULL AND assigned_to <> ''").
+			Distinct("assigned_to").Pluck("assigned_to", &assignees).Error
+		if err != nil {
+			return nil, err
+		}
+
+		for _, assignee := range assignees {
+			staffID, ok := byKey[normaliseAssignee(assignee)]
+			if !ok {
+				result.Unmatched = append(result.Unmatched, assignee)
+				continue
+			}
+			update := tx.Model(model).
+				Where("assigned_staff_id IS NULL AND assigned_to = ?", assignee).
+				Update("assigned_staff_id", staffID)
+			if update.Error != nil {
+				return nil, update.Error
+			}
+			result.Matched += update.RowsAffected
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+func normaliseAssignee(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// WorkloadItem is one open piece of work assigned to a staff member
+type WorkloadItem struct {
+	Type          string    `json:"type"` // service-request, housekeeping-request, maintenance-issue
+	ID            uint      `json:"id"`
+	ReservationID uint      `json:"reservation_id"`
+	Kind          string    `json:"kind"` // service type, request type or issue type
+	Status        string    `json:"status"`
+	Priority      string    `json:"priority"`
+	Description   string    `json:"description"`
+	RequestedAt   time.Time `json:"requested_at"`
+}
+
+// StaffWorkload is the open work assigned to one staff member
+type StaffWorkload struct {
+	StaffID              uint           `json:"staff_id"`
+	Name                 string         `json:"name"`
+	Role                 string         `json:"role"`
+	ServiceRequests      int            `json:"service_requests"`
+	HousekeepingRequests int            `json:"housekeeping_requests"`
+	MaintenanceIssues    int            `json:"maintenance_issues"`
+	Total                int            `json:"total"`
+	OldestRequestedAt    *time.Time     `json:"oldest_requested_at"`
+	Items                []WorkloadItem `json:"items,omitempty"`
+}
+
+// CalculateStaffWorkloads returns the open service requests, housekeeping
+// requests and maintenance issues assigned to each active staff member, or
+// to the given staff only when staffIDs is not empty. Items are listed
+// oldest first.
+func CalculateStaffWorkloads(tx *gorm.DB, staffIDs ...uint) ([]StaffWorkload, error) {
+	query := tx.Where("status = ?", StaffStatusActive)
+	if len(staffIDs) > 0 {
+		query = tx.Where("id IN ?", staffIDs)
+	}
+	var staff []Staff
+	if err := query.Order("role, name").Find(&staff).Error; err != nil {
+		return nil, err
+	}
+	if len(staff) == 0 {
+		return []StaffWorkload{}, nil
+	}
+	ids := make([]uint, len(staff))
+	for i, member := range staff {
+		ids[i] = member.ID
+	}
+
+	items := make(map[uint][]WorkloadItem)
+
+	var serviceRequests []ServiceRequest
+	err := tx.Where("assigned_staff_id IN ? AND status IN ?", ids,
+		[]string{RequestStatusPending, RequestStatusInProgress}).
+		Order("requested_at").Find(&serviceRequests).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range serviceRequests {
+		items[*req.AssignedStaffID] = append(items[*req.AssignedStaffID], WorkloadItem{
+			Type: WorkTypeServiceRequest, ID: req.ID, ReservationID: req.ReservationID,
+			Kind: req.ServiceType, Status: req.Status, Priority: req.Priority,
+			Description: req.Description, RequestedAt: req.RequestedAt,
+		})
+	}
+
+	var housekeepingRequests []HousekeepingRequest
+	err = tx.Where("assigned_staff_id IN ? AND status IN ?", ids,
+		[]string{RequestStatusPending, RequestStatusInProgress}).
+		Order("requested_at").Find(&housekeepingRequests).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, req := range housekeepingRequests {
+		items[*req.AssignedStaffID] = append(items[*req.AssignedStaffID], WorkloadItem{
+			Type: WorkTypeHousekeepingRequest, ID: req.ID, ReservationID: req.ReservationID,
+			Kind: req.RequestType, Status: req.Status, Description: req.Description,
+			RequestedAt: req.RequestedAt,
+		})
+	}
+
+	var issues []MaintenanceIssue
+	err = tx.Where("assigned_staff_id IN ? AND status <> ?", ids, IssueStatusResolved).
+		Order("reported_at").Find(&issues).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, issue := range issues {
+		items[*issue.AssignedStaffID] = append(items[*issue.AssignedStaffID], WorkloadItem{
+			Type: WorkTypeMaintenanceIssue, ID: issue.ID, ReservationID: issue.ReservationID,
+			Kind: issue.IssueType, Status: issue.Status, Priority: issue.Priority,
+			Description: issue.Description, RequestedAt: issue.ReportedAt,
+		})
+	}
+
+	workloads := make([]StaffWorkload, 0, len(staff))
+	for _, member := range staff {
+		workload := StaffWorkload{
+			StaffID: member.ID,
+			Name:    member.Name,
+			Role:    member.Role,
+			Items:   items[member.ID],
+		}
+		sort.SliceStable(workload.Items, func(i, j int) bool {
+			return workload.Items[i].RequestedAt.Before(workload.Items[j].RequestedAt)
+		})
+		for _, item := range workload.Items {
+			switch item.Type {
+			case WorkTypeServiceRequest:
+				workload.ServiceRequests++
+			case WorkTypeHousekeepingRequest:
+				workload.HousekeepingRequests++
+			case WorkTypeMaintenanceIssue:
+				workload.MaintenanceIssues++
+			}
+		}
+		if len(workload.Items) > 0 {
+			workload.OldestRequestedAt = &workload.Items[0].RequestedAt
+		}
+		workload.Total = len(workload.Items)
+		workloads = append(workloads, workload)
+	}
+	return workloads, nil
+}
@@ -0,0 +1,313 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Shift statuses
+const (
+	ShiftStatusScheduled = "scheduled"
+	ShiftStatusOnDuty    = "on-duty"
+	ShiftStatusCompleted = "completed"
+	ShiftStatusMissed    = "missed"
+	ShiftStatusCancelled = "cancelled"
+)
+
+const (
+	// clockInEarlyWindow is how long before a rostered start staff may clock
+	// in against that shift
+	clockInEarlyWindow = 60 * time.Minute
+	// DefaultUnplannedShiftHours is the length given to a shift created by
+	// clocking in without one on the roster
+	DefaultUnplannedShiftHours = 8
+	// WeeklyOvertimeHours is the worked hours per Monday-to-Sunday week above
+	// which time counts as overtime
+	WeeklyOvertimeHours = 40.0
+	// DefaultPayPeriodDays is the length of a pay period
+	DefaultPayPeriodDays = 14
+)
+
+// Errors returned by clock-in and clock-out
+var (
+	ErrAlreadyClockedIn = errors.New("staff member is already clocked in")
+	ErrNotClockedIn     = errors.New("staff member is not clocked in")
+	ErrInvalidShift     = errors.New("shift must end after it starts")
+)
+
+// CreateShift adds a shift to the roster. The role defaults to the staff
+// member's own.
+func CreateShift(tx *gorm.DB, shift *StaffShift) error {
+	if !shift.EndsAt.After(shift.StartsAt) {
+		return ErrInvalidShift
+	}
+	var staff Staff
+	if err := tx.First(&staff, shift.StaffID).Error; err != nil {
+		return err
+	}
+	if staff.Status != StaffStatusActive {
+		return fmt.Errorf("%w: %s", ErrStaffInactive, staff.Name)
+	}
+	if shift.Role == "" {
+		shift.Role = staff.Role
+	}
+	shift.ShiftDate = atHour(shift.StartsAt, 0)
+	shift.Status = ShiftStatusScheduled
+	return tx.Create(shift).Error
+}
+
+// ClockIn starts the staff member's rostered shift, or an unplanned one if
+// nothing on the roster starts within the hour or is under way.
+func ClockIn(tx *gorm.DB, staffID uint, now time.Time) (*StaffShift, error) {
+	var staff Staff
+	if err := tx.First(&staff, staffID).Error; err != nil {
+		return nil, err
+	}
+	if staff.Status != StaffStatusActive {
+		return nil, fmt.Errorf("%w: %s", ErrStaffInactive, staff.Name)
+	}
+
+	var open int64
+	err := tx.Model(&StaffShift{}).
+		Where("staff_id = ? AND status = ?", staffID, ShiftStatusOnDuty).
+		Count(&open).Error
+	if err != nil {
+		return nil, err
+	}
+	if open > 0 {
+		return nil, ErrAlreadyClockedIn
+	}
+
+	var shift StaffShift
+	result := tx.Where("staff_id = ? AND status = ? AND starts_at <= ? AND ends_at > ?",
+		staffID, ShiftStatusScheduled, now.Add(clockInEarlyWindow), now).
+		Order("starts_at").Limit(1).Find(&shift)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		shift = StaffShift{
+			StaffID:   staffID,
+			Role:      staff.Role,
+			ShiftDate: atHour(now, 0),
+			StartsAt:  now,
+			EndsAt:    now.Add(DefaultUnplannedShiftHours * time.Hour),
+			Unplanned: true,
+			Status:    ShiftStatusScheduled,
+		}
+		if err := tx.Create(&shift).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	shift.ClockInAt = &now
+	shift.Status = ShiftStatusOnDuty
+	err = tx.Model(&shift).Updates(map[string]interface{}{
+		"clock_in_at": now,
+		"status":      ShiftStatusOnDuty,
+	}).Error
+	return &shift, err
+}
+
+// ClockOut ends the staff member's current shift
+func ClockOut(tx *gorm.DB, staffID uint, now time.Time) (*StaffShift, error) {
+	var shift StaffShift
+	result := tx.Where("staff_id = ? AND status = ?", staffID, ShiftStatusOnDuty).
+		Order("clock_in_at DESC").Limit(1).Find(&shift)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrNotClockedIn
+	}
+
+	shift.ClockOutAt = &now
+	shift.Status = ShiftStatusCompleted
+	err := tx.Model(&shift).Updates(map[string]interface{}{
+		"clock_out_at": now,
+		"status":       ShiftStatusCompleted,
+	}).Error
+	return &shift, err
+}
+
+// MarkMissedShifts marks rostered shifts that ended without a clock-in as
+// missed. It is run periodically alongside the other background jobs.
+func MarkMissedShifts(tx *gorm.DB, now time.Time) (int64, error) {
+	result := tx.Model(&StaffShift{}).
+		Where("status = ? AND ends_at < ?", ShiftStatusScheduled, now).
+		Update("status", ShiftStatusMissed)
+	return result.RowsAffected, result.Error
+}
+
+// OnDutyStaff returns active staff who are clocked in now, working the given
+// role or any role when role is empty
+func OnDutyStaff(tx *gorm.DB, role string) ([]Staff, error) {
+	shifts := tx.Model(&StaffShift{}).Select("staff_id").Where("status = ?", ShiftStatusOnDuty)
+	if role != "" {
+		shifts = shifts.Where("role = ?", role)
+	}
+	var staff []Staff
+	err := tx.Where("status = ? AND id IN (?)", StaffStatusActive, shifts).
+		Order("id").Find(&staff).Error
+	return staff, err
+}
+
+// IsOnDuty reports whether the staff member is clocked in
+func IsOnDuty(tx *gorm.DB, staffID uint) (bool, error) {
+	var open int64
+	err := tx.Model(&StaffShift{}).
+		Where("staff_id = ? AND status = ?", staffID, ShiftStatusOnDuty).
+		Count(&open).Error
+	return open > 0, err
+}
+
+// WorkedHours returns the paid hours of a clocked shift, less its break. A
+// shift still in progress counts up to now.
+func (s StaffShift) WorkedHours(now time.Time) float64 {
+	if s.ClockInAt == nil {
+		return 0
+	}
+	end := now
+	if s.ClockOutAt != nil {
+		end = *s.ClockOutAt
+	}
+	worked := end.Sub(*s.ClockInAt) - time.Duration(s.BreakMinutes)*time.Minute
+	if worked < 0 {
+		return 0
+	}
+	return worked.Hours()
+}
+
+// ScheduledHours returns the rostered hours of the shift, less its break
+func (s StaffShift) ScheduledHours() float64 {
+	hours := s.EndsAt.Sub(s.StartsAt).Hours() - float64(s.BreakMinutes)/60
+	return math.Max(hours, 0)
+}
+
+// PayPeriod is a span of days that hours are reported and paid for
+type PayPeriod struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"` // exclusive
+}
+
+// PayPeriodContaining returns the pay period of lengthDays that contains t,
+// counting periods from anchor, the start of any past pay period.
+func PayPeriodContaining(anchor time.Time, lengthDays int, t time.Time) PayPeriod {
+	if lengthDays <= 0 {
+		lengthDays = DefaultPayPeriodDays
+	}
+	anchor = atHour(anchor, 0)
+	days := int(math.Floor(atHour(t, 0).Sub(anchor).Hours() / 24))
+	offset := days - ((days%lengthDays)+lengthDays)%lengthDays
+	start := anchor.AddDate(0, 0, offset)
+	return PayPeriod{Start: start, End: start.AddDate(0, 0, lengthDays)}
+}
+
+// StaffHours summarises one staff member's shifts in a pay period
+type StaffHours struct {
+	StaffID        uint    `json:"staff_id"`
+	Name           string  `json:"name"`
+	Role           string  `json:"role"`
+	Shifts         int     `json:"shifts"`
+	MissedShifts   int     `json:"missed_shifts"`
+	ScheduledHours float64 `json:"scheduled_hours"`
+	WorkedHours    float64 `json:"worked_hours"`
+	RegularHours   float64 `json:"regular_hours"`
+	OvertimeHours  float64 `json:"overtime_hours"`
+}
+
+// CalculateStaffHours reports scheduled, worked and overtime hours per staff
+// member for shifts starting in the pay period. Overtime is worked time
+// beyond WeeklyOvertimeHours in each Monday-to-Sunday week.
+func CalculateStaffHours(tx *gorm.DB, period PayPeriod, now time.Time) ([]StaffHours, error) {
+	var shifts []StaffShift
+	err := tx.Preload("Staff").
+		Where("starts_at >= ? AND starts_at < ? AND status <> ?", period.Start, period.End, ShiftStatusCancelled).
+		Order("staff_id, starts_at").Find(&shifts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	byStaff := make(map[uint]*StaffHours)
+	weekly := make(map[uint]map[time.Time]float64)
+	var order []uint
+	for _, shift := range shifts {
+		hours, ok := byStaff[shift.StaffID]
+		if !ok {
+			hours = &StaffHours{StaffID: shift.StaffID, Name: shift.Staff.Name, Role: shift.Staff.Role}
+			byStaff[shift.StaffID] = hours
+			weekly[shift.StaffID] = make(map[time.Time]float64)
+			order = append(order, shift.StaffID)
+		}
+		hours.Shifts++
+		if shift.Status == ShiftStatusMissed {
+			hours.MissedShifts++
+		}
+		if !shift.Unplanned {
+			hours.ScheduledHours += shift.ScheduledHours()
+		}
+		worked := shift.WorkedHours(now)
+		hours.WorkedHours += worked
+		weekly[shift.StaffID][weekStart(shift.StartsAt)] += worked
+	}
+
+	report := make([]StaffHours, 0, len(order))
+	for _, staffID := range order {
+		hours := byStaff[staffID]
+		for _, worked := range weekly[staffID] {
+			hours.OvertimeHours += math.Max(worked-WeeklyOvertimeHours, 0)
+		}
+		hours.RegularHours = hours.WorkedHours - hours.OvertimeHours
+		hours.ScheduledHours = roundHours(hours.ScheduledHours)
+		hours.WorkedHours = roundHours(hours.WorkedHours)
+		hours.RegularHours = roundHours(hours.RegularHours)
+		hours.OvertimeHours = roundHours(hours.OvertimeHours)
+		report = append(report, *hours)
+	}
+	return report, nil
+}
+
+// WriteStaffHoursCSV writes an hours report as CSV for payroll
+func WriteStaffHoursCSV(w io.Writer, period PayPeriod, report []StaffHours) error {
+	out := csv.NewWriter(w)
+	header := []string{"period_start", "period_end", "staff_id", "name", "role", "shifts", "missed_shifts",
+		"scheduled_hours", "worked_hours", "regular_hours", "overtime_hours"}
+	if err := out.Write(header); err != nil {
+		return err
+	}
+	start := period.Start.Format("2006-01-02")
+	end := period.End.AddDate(0, 0, -1).Format("2006-01-02")
+	for _, row := range report {
+		record := []string{
+			start, end,
+			strconv.FormatUint(uint64(row.StaffID), 10), row.Name, row.Role,
+			strconv.Itoa(row.Shifts), strconv.Itoa(row.MissedShifts),
+			strconv.FormatFloat(row.ScheduledHours, 'f', 2, 64),
+			strconv.FormatFloat(row.WorkedHours, 'f', 2, 64),
+			strconv.FormatFloat(row.RegularHours, 'f', 2, 64),
+			strconv.FormatFloat(row.OvertimeHours, 'f', 2, 64),
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// weekStart returns midnight on the Monday of t's week
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7
+	return atHour(t, 0).AddDate(0, 0, -offset)
+}
+
+func roundHours(hours float64) float64 {
+	return math.Round(hours*100) / 100
+}
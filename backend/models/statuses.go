@@ -0,0 +1,42 @@
+package models
+
+// Reservation statuses
+const (
+	ReservationStatusPending    = "pending"
+	ReservationStatusConfirmed  = "confirmed"
+	ReservationStatusCheckedIn  = "checked-in"
+	ReservationStatusCheckedOut = "checked-out"
+	ReservationStatusCancelled  = "cancelled"
+	ReservationStatusNoShow     = "no-show"
+)
+
+// Room statuses
+const (
+	RoomStatusAvailable   = "available"
+	RoomStatusOccupied    = "occupied"
+	RoomStatusMaintenance = "maintenance"
+	RoomStatusCleaning    = "cleaning"
+)
+
+// Staff roles
+const (
+	StaffRoleManager      = "manager"
+	StaffRoleHousekeeping = "housekeeping"
+	StaffRoleMaintenance  = "maintenance"
+	StaffRoleFrontDesk    = "front-desk"
+	StaffRoleRoomService  = "room-service"
+)
+
+// Staff statuses
+const (
+	StaffStatusActive   = "active"
+	StaffStatusInactive = "inactive"
+)
+
+// Service and housekeeping request statuses
+const (
+	RequestStatusPending    = "pending"
+	RequestStatusInProgress = "in-progress"
+	RequestStatusCompleted  = "completed"
+	RequestStatusCancelled  = "cancelled"
+)
@@ -0,0 +1,191 @@
+package models
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Normalised task statuses shared by every request type in the inbox
+const (
+	InboxStatusOpen       = "open"
+	InboxStatusInProgress = "in-progress"
+	InboxStatusDone       = "done"
+	InboxStatusCancelled  = "cancelled"
+)
+
+// Inbox sort orders
+const (
+	InboxSortOldest   = "oldest"
+	InboxSortPriority = "priority"
+)
+
+// inboxStatuses maps each source table's status vocabulary onto the inbox one
+var inboxStatuses = map[string]map[string]string{
+	WorkTypeServiceRequest: {
+		RequestStatusPending:    InboxStatusOpen,
+		RequestStatusInProgress: InboxStatusInProgress,
+		RequestStatusCompleted:  InboxStatusDone,
+		RequestStatusCancelled:  InboxStatusCancelled,
+	},
+	WorkTypeHousekeepingRequest: {
+		RequestStatusPending:    InboxStatusOpen,
+		RequestStatusInProgress: InboxStatusInProgress,
+		RequestStatusCompleted:  InboxStatusDone,
+		RequestStatusCancelled:  InboxStatusCancelled,
+	},
+	WorkTypeMaintenanceIssue: {
+		IssueStatusReported:     InboxStatusOpen,
+		IssueStatusAcknowledged: InboxStatusInProgress,
+		IssueStatusInProgress:   InboxStatusInProgress,
+		IssueStatusResolved:     InboxStatusDone,
+	},
+}
+
+// InboxStatus returns the normalised status for a request type's own status
+func InboxStatus(workType, status string) string {
+	if normalised, ok := inboxStatuses[workType][status]; ok {
+		return normalised
+	}
+	return InboxStatusOpen
+}
+
+// InboxTask is a service request, housekeeping request or maintenance issue
+// seen through the unified inbox
+type InboxTask struct {
+	Type            string    `json:"type"` // service-request, housekeeping-request, maintenance-issue
+	ID              uint      `json:"id"`
+	ReservationID   uint      `json:"reservation_id"`
+	GuestID         uint      `json:"guest_id"`
+	RoomID          *uint     `json:"room_id"`
+	Kind            string    `json:"kind"` // service type, request type or issue type
+	Role            string    `json:"role"` // staff role that handles the task
+	Priority        string    `json:"priority"`
+	Status          string    `json:"status"`        // open, in-progress, done, cancelled
+	SourceStatus    string    `json:"source_status"` // status in the request's own table
+	AssignedStaffID *uint     `json:"assigned_staff_id"`
+	Description     string    `json:"description"`
+	RequestedAt     time.Time `json:"requested_at"`
+}
+
+// TaskInboxFilter narrows the inbox. Zero values do not filter, except
+// Statuses, which defaults to open and in-progress work.
+type TaskInboxFilter struct {
+	Types           []string
+	Role            string
+	AssignedStaffID *uint
+	Unassigned      bool
+	RoomID          *uint
+	Priority        string
+	Statuses        []string
+	OlderThan       time.Duration // requested at least this long ago
+	NewerThan       time.Duration // requested at most this long ago
+	Sort            string        // oldest (default) or priority
+	Page            int
+	PageSize        int
+}
+
+// ListInboxTasks returns one page of the unified inbox and the total number
+// of matching tasks. Age filters count back from now.
+func ListInboxTasks(tx *gorm.DB, filter TaskInboxFilter, now time.Time) ([]InboxTask, int64, error) {
+	query := tx.Table("(?) AS tasks", inboxTasksQuery(tx))
+
+	statuses := filter.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{InboxStatusOpen, InboxStatusInProgress}
+	}
+	query = query.Where("status IN ?", statuses)
+	if len(filter.Types) > 0 {
+		query = query.Where("type IN ?", filter.Types)
+	}
+	if filter.Role != "" {
+		query = query.Where("role = ?", filter.Role)
+	}
+	if filter.AssignedStaffID != nil {
+		query = query.Where("assigned_staff_id = ?", *filter.AssignedStaffID)
+	} else if filter.Unassigned {
+		query = query.Where("assigned_staff_id IS NULL")
+	}
+	if filter.RoomID != nil {
+		query = query.Where("room_id = ?", *filter.RoomID)
+	}
+	if filter.Priority != "" {
+		query = query.Where("priority = ?", filter.Priority)
+	}
+	if filter.OlderThan > 0 {
+		query = query.Where("requested_at <= ?", now.Add(-filter.OlderThan))
+	}
+	if filter.NewerThan > 0 {
+		query = query.Where("requested_at >= ?", now.Add(-filter.NewerThan))
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	if filter.Sort == InboxSortPriority {
+		query = query.Order("CASE priority WHEN 'high' THEN 0 WHEN 'medium' THEN 1 ELSE 2 END")
+	}
+	query = query.Order("requested_at").Order("type").Order("id")
+
+	page, pageSize := filter.Page, filter.PageSize
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 {
+		pageSize = 20
+	}
+	var tasks []InboxTask
+	err := query.Offset((page - 1) * pageSize).Limit(pageSize).Scan(&tasks).Error
+	return tasks, total, err
+}
+
+// inboxTasksQuery selects the three request tables into InboxTask columns.
+// Requests without a room of their own take it from their reservation.
+// Housekeeping requests carry no priority; immediate ones count as high.
+func inboxTasksQuery(tx *gorm.DB) *gorm.DB {
+	services := tx.Table("service_requests AS s").
+		Select("? AS type, s.id, s.reservation_id, s.guest_id, r.room_id, s.service_type AS kind, "+
+			sqlCase("s.service_type", serviceTypeRoles, StaffRoleFrontDesk)+" AS role, "+
+			"s.priority, "+sqlCase("s.status", inboxStatuses[WorkTypeServiceRequest], InboxStatusOpen)+" AS status, "+
+			"s.status AS source_status, s.assigned_staff_id, s.description, s.requested_at",
+			WorkTypeServiceRequest).
+		Joins("LEFT JOIN reservations r ON r.id = s.reservation_id")
+
+	housekeeping := tx.Table("housekeeping_requests AS h").
+		Select("? AS type, h.id, h.reservation_id, h.guest_id, r.room_id, h.request_type AS kind, ? AS role, "+
+			"CASE h.schedule_time WHEN 'immediate' THEN 'high' ELSE 'medium' END AS priority, "+
+			sqlCase("h.status", inboxStatuses[WorkTypeHousekeepingRequest], InboxStatusOpen)+" AS status, "+
+			"h.status AS source_status, h.assigned_staff_id, h.description, h.requested_at",
+			WorkTypeHousekeepingRequest, StaffRoleHousekeeping).
+		Joins("LEFT JOIN reservations r ON r.id = h.reservation_id")
+
+	issues := tx.Table("maintenance_issues AS m").
+		Select("? AS type, m.id, m.reservation_id, m.guest_id, m.room_id, m.issue_type AS kind, ? AS role, "+
+			"m.priority, "+sqlCase("m.status", inboxStatuses[WorkTypeMaintenanceIssue], InboxStatusOpen)+" AS status, "+
+			"m.status AS source_status, m.assigned_staff_id, m.description, m.reported_at AS requested_at",
+			WorkTypeMaintenanceIssue, StaffRoleMaintenance)
+
+	return tx.Raw("? UNION ALL ? UNION ALL ?", services, housekeeping, issues)
+}
+
+// sqlCase builds a CASE expression mapping column values through mapping.
+// The mapping holds only constants from this package, never user input.
+func sqlCase(column string, mapping map[string]string, otherwise string) string {
+	keys := make([]string, 0, len(mapping))
+	for key := range mapping {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	b.WriteString("CASE " + column)
+	for _, key := range keys {
+		b.WriteString(" WHEN '" + key + "' THEN '" + mapping[key] + "'")
+	}
+	b.WriteString(" ELSE '" + otherwise + "' END")
+	return b.String()
+}
@@ -0,0 +1,263 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Waitlist entry statuses
+const (
+	WaitlistStatusWaiting   = "waiting"
+	WaitlistStatusOffered   = "offered"
+	WaitlistStatusAccepted  = "accepted"
+	WaitlistStatusDeclined  = "declined"
+	WaitlistStatusExpired   = "expired"
+	WaitlistStatusCancelled = "cancelled"
+)
+
+// WaitlistOfferHours is how long a waitlisted guest has to accept an offer
+// before the held room is released
+const WaitlistOfferHours = 24
+
+// Waitlist errors
+var (
+	ErrNoRoomTypes  = errors.New("at least one acceptable room type is required")
+	ErrOfferExpired = errors.New("waitlist offer has expired")
+)
+
+var waitlistTransitions = map[string][]string{
+	WaitlistStatusWaiting: {WaitlistStatusOffered, WaitlistStatusCancelled},
+	WaitlistStatusOffered: {WaitlistStatusAccepted, WaitlistStatusDeclined, WaitlistStatusExpired, WaitlistStatusCancelled},
+}
+
+// AddToWaitlist stores a waiting guest and makes them an offer straight
+// away if one of their room types is already free
+func AddToWaitlist(tx *gorm.DB, entry *WaitlistEntry, now time.Time) error {
+	if !entry.CheckOutDate.After(entry.CheckInDate) || entry.CheckInDate.Before(atHour(now, 0)) {
+		return ErrInvalidStayDates
+	}
+	if len(entry.RoomTypes) == 0 {
+		return ErrNoRoomTypes
+	}
+	if entry.Guests < 1 {
+		entry.Guests = 1
+	}
+	entry.Status = WaitlistStatusWaiting
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+	_, err := offerWaitlistEntry(tx, entry, now)
+	return err
+}
+
+// MatchWaitlist offers rooms to waiting guests, first come first served,
+// whose dates overlap from–to and who accept roomType (any type if empty).
+// It is called whenever a reservation releases a room, and returns the
+// entries that received an offer.
+func MatchWaitlist(tx *gorm.DB, roomType string, from, to time.Time, now time.Time) ([]WaitlistEntry, error) {
+	var entries []WaitlistEntry
+	err := tx.Where("status = ? AND check_in_date >= ? AND check_in_date < ? AND check_out_date > ?",
+		WaitlistStatusWaiting, atHour(now, 0), to, from).
+		Order("created_at, id").Find(&entries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var offered []WaitlistEntry
+	for i := range entries {
+		if roomType != "" && !acceptsRoomType(entries[i], roomType) {
+			continue
+		}
+		ok, err := offerWaitlistEntry(tx, &entries[i], now)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			offered = append(offered, entries[i])
+		}
+	}
+	return offered, nil
+}
+
+// offerWaitlistEntry holds the first free room of the entry's most
+// preferred available type as a pending reservation at the best available
+// rate, expiring after WaitlistOfferHours. It reports false if no
+// acceptable room is free.
+func offerWaitlistEntry(tx *gorm.DB, entry *WaitlistEntry, now time.Time) (bool, error) {
+	var room *Room
+	for _, roomType := range entry.RoomTypes {
+		sellable, err := SellableRoomsOfType(tx, fmt.Sprint(roomType), entry.CheckInDate, entry.CheckOutDate, 0)
+		if err != nil {
+			return false, err
+		}
+		if sellable <= 0 {
+			continue
+		}
+		room, err = findFreeRoomOfType(tx, fmt.Sprint(roomType), entry.Guests, entry.CheckInDate, entry.CheckOutDate, 0)
+		if errors.Is(err, ErrNoRoomAvailable) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		break
+	}
+	if room == nil {
+		return false, nil
+	}
+
+	expires := now.Add(WaitlistOfferHours * time.Hour)
+	reservation := Reservation{
+		GuestID:       entry.GuestID,
+		RoomID:        room.ID,
+		CheckInDate:   entry.CheckInDate,
+		CheckOutDate:  entry.CheckOutDate,
+		Status:        ReservationStatusPending,
+		HoldExpiresAt: &expires,
+	}
+	if _, err := PriceReservation(tx, &reservation, entry.Guests, now); err != nil {
+		return false, err
+	}
+	err := InsertWithGeneratedID(tx, BookingIDScheme, now, func(id string) { reservation.BookingID = id }, &reservation)
+	if err != nil {
+		return false, err
+	}
+
+	entry.Status = WaitlistStatusOffered
+	entry.ReservationID = &reservation.ID
+	entry.OfferedAt = &now
+	entry.OfferExpiresAt = &expires
+	return true, tx.Model(entry).Updates(map[string]interface{}{
+		"status":           WaitlistStatusOffered,
+		"reservation_id":   reservation.ID,
+		"offered_at":       now,
+		"offer_expires_at": expires,
+	}).Error
+}
+
+// AcceptWaitlistOffer takes up an unexpired offer. The held reservation
+// stays pending, without an expiry, until it is paid and confirmed.
+func AcceptWaitlistOffer(tx *gorm.DB, entry *WaitlistEntry, now time.Time) error {
+	if !canTransition(waitlistTransitions, entry.Status, WaitlistStatusAccepted) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, entry.Status, WaitlistStatusAccepted)
+	}
+	if entry.OfferExpiresAt != nil && !now.Before(*entry.OfferExpiresAt) {
+		return ErrOfferExpired
+	}
+	err := tx.Model(&Reservation{}).Where("id = ?", entry.ReservationID).Update("hold_expires_at", nil).Error
+	if err != nil {
+		return err
+	}
+	return respondToOffer(tx, entry, WaitlistStatusAccepted, now)
+}
+
+// DeclineWaitlistOffer turns an offer down, releasing the held room to the
+// next guest on the waitlist
+func DeclineWaitlistOffer(tx *gorm.DB, entry *WaitlistEntry, now time.Time) error {
+	return closeWaitlistEntry(tx, entry, WaitlistStatusDeclined, "Waitlist offer declined", now)
+}
+
+// CancelWaitlistEntry takes a guest off the waitlist, releasing any room held
+// for them
+func CancelWaitlistEntry(tx *gorm.DB, entry *WaitlistEntry, now time.Time) error {
+	return closeWaitlistEntry(tx, entry, WaitlistStatusCancelled, "Waitlist entry cancelled", now)
+}
+
+func closeWaitlistEntry(tx *gorm.DB, entry *WaitlistEntry, status, reason string, now time.Time) error {
+	if !canTransition(waitlistTransitions, entry.Status, status) {
+		return fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, entry.Status, status)
+	}
+	offered := entry.Status == WaitlistStatusOffered
+	if err := respondToOffer(tx, entry, status, now); err != nil {
+		return err
+	}
+	if !offered || entry.ReservationID == nil {
+		return nil
+	}
+	var reservation Reservation
+	if err := tx.First(&reservation, *entry.ReservationID).Error; err != nil {
+		return err
+	}
+	return releaseHold(tx, &reservation, reason, now)
+}
+
+func respondToOffer(tx *gorm.DB, entry *WaitlistEntry, status string, now time.Time) error {
+	entry.Status = status
+	entry.RespondedAt = &now
+	return tx.Model(entry).Updates(map[string]interface{}{
+		"status":       status,
+		"responded_at": now,
+	}).Error
+}
+
+// ReleaseExpiredHolds cancels pending reservations whose hold has expired,
+// marks the waitlist offers behind them expired and offers the freed rooms
+// to the waitlist. It is run every few minutes.
+func ReleaseExpiredHolds(tx *gorm.DB, now time.Time) ([]Reservation, error) {
+	var reservations []Reservation
+	err := tx.Where("status = ? AND hold_expires_at <= ?", ReservationStatusPending, now).
+		Order("hold_expires_at, id").Find(&reservations).Error
+	if err != nil {
+		return nil, err
+	}
+	for i := range reservations {
+		err := tx.Model(&WaitlistEntry{}).
+			Where("reservation_id = ? AND status = ?", reservations[i].ID, WaitlistStatusOffered).
+			Update("status", WaitlistStatusExpired).Error
+		if err != nil {
+			return nil, err
+		}
+		if err := releaseHold(tx, &reservations[i], "Hold expired", now); err != nil {
+			return nil, err
+		}
+	}
+	return reservations, nil
+}
+
+// releaseHold cancels a held reservation without penalty, gives back its
+// promotion uses and offers its room to the waitlist
+func releaseHold(tx *gorm.DB, reservation *Reservation, reason string, now time.Time) error {
+	reservation.Status = ReservationStatusCancelled
+	reservation.CancelledAt = &now
+	reservation.CancellationReason = reason
+	reservation.HoldExpiresAt = nil
+	err := tx.Model(reservation).Updates(map[string]interface{}{
+		"status":              ReservationStatusCancelled,
+		"cancelled_at":        now,
+		"cancellation_reason": reason,
+		"hold_expires_at":     nil,
+	}).Error
+	if err != nil {
+		return err
+	}
+	if err := releasePromotions(tx, reservation.ID); err != nil {
+		return err
+	}
+	return matchReleasedStay(tx, reservation.RoomID, reservation.CheckInDate, reservation.CheckOutDate, now)
+}
+
+// matchReleasedStay gives a room released for checkIn–checkOut first to
+// overbooked reservations of its type, then to guests waiting for it
+func matchReleasedStay(tx *gorm.DB, roomID uint, checkIn, checkOut, now time.Time) error {
+	var room Room
+	if err := tx.First(&room, roomID).Error; err != nil {
+		return err
+	}
+	if err := relocateOverbooked(tx, room.RoomType, checkIn, checkOut, now); err != nil {
+		return err
+	}
+	_, err := MatchWaitlist(tx, room.RoomType, checkIn, checkOut, now)
+	return err
+}
+
+func acceptsRoomType(entry WaitlistEntry, roomType string) bool {
+	for _, accepted := range entry.RoomTypes {
+		if fmt.Sprint(accepted) == roomType {
+			return true
+		}
+	}
+	return false
+}
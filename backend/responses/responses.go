@@ -28,16 +28,20 @@ type PaginatedResponse struct {
 // ===== GUEST RESPONSES =====
 
 type GuestResponse struct {
-	ID          uint      `json:"id"`
-	Name        string    `json:"name"`
-	Email       string    `json:"email"`
-	Phone       string    `json:"phone"`
-	Nationality string    `json:"nationality"`
-	IDType      string    `json:"id_type"`
-	IDNumber    string    `json:"id_number"`
-	JoinDate    time.Time `json:"join_date"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID                   uint      `json:"id"`
+	Name                 string    `json:"name"`
+	Email                string    `json:"email"`
+	Phone                string    `json:"phone"`
+	Nationality          string    `json:"nationality"`
+	IDType               string    `json:"id_type"`
+	IDNumber             string    `json:"id_number"`
+	JoinDate             time.Time `json:"join_date"`
+	CorporateAccountID   *uint     `json:"corporate_account_id"`
+	CorporateAccountName string    `json:"corporate_account_name,omitempty"`
+	LoyaltyTier          string    `json:"loyalty_tier"`
+	LoyaltyPoints        int       `json:"loyalty_points"`
+	CreatedAt            time.Time `json:"created_at"`
+	UpdatedAt            time.Time `json:"updated_at"`
 }
 
 type GuestDetailResponse struct {
@@ -53,6 +57,7 @@ type GuestDetailResponse struct {
 	Preferences  GuestPreferencesResponse `json:"preferences"`
 	AIInsights   GuestAIInsightsResponse  `json:"ai_insights"`
 	Statistics   GuestStatisticsResponse  `json:"statistics"`
+	Loyalty      LoyaltyStatusResponse    `json:"loyalty"`
 	ServiceUsage []ServiceUsageResponse   `json:"service_usage"`
 	CreatedAt    time.Time                `json:"created_at"`
 	UpdatedAt    time.Time                `json:"updated_at"`
@@ -90,21 +95,102 @@ type ServiceUsageResponse struct {
 	Label string `json:"label"`
 }
 
+// ===== LOYALTY RESPONSES =====
+
+type LoyaltyStatusResponse struct {
+	Tier            string    `json:"tier"`
+	Points          int       `json:"points"`
+	PointsValue     float64   `json:"points_value"`
+	WindowStart     time.Time `json:"window_start"`
+	Stays           int       `json:"stays"`
+	Spend           float64   `json:"spend"`
+	NextTier        string    `json:"next_tier,omitempty"`
+	StaysToNextTier int       `json:"stays_to_next_tier"`
+	SpendToNextTier float64   `json:"spend_to_next_tier"`
+}
+
+type LoyaltyTransactionResponse struct {
+	ID            uint      `json:"id"`
+	GuestID       uint      `json:"guest_id"`
+	ReservationID *uint     `json:"reservation_id"`
+	EntryType     string    `json:"entry_type"`
+	Points        int       `json:"points"`
+	Balance       int       `json:"balance"`
+	Description   string    `json:"description"`
+	FolioChargeID *uint     `json:"folio_charge_id"`
+	StaffID       *uint     `json:"staff_id"`
+	PostedAt      time.Time `json:"posted_at"`
+}
+
+type LoyaltyTierResponse struct {
+	ID           uint    `json:"id"`
+	Name         string  `json:"name"`
+	Rank         int     `json:"rank"`
+	MinStays     int     `json:"min_stays"`
+	MinSpend     float64 `json:"min_spend"`
+	BonusPercent float64 `json:"bonus_percent"`
+	Active       bool    `json:"active"`
+}
+
 // ===== RESERVATION RESPONSES =====
 
 type ReservationResponse struct {
-	ID           uint      `json:"id"`
-	BookingID    string    `json:"booking_id"`
-	GuestID      uint      `json:"guest_id"`
-	RoomID       uint      `json:"room_id"`
-	CheckInDate  time.Time `json:"check_in_date"`
-	CheckOutDate time.Time `json:"check_out_date"`
-	Nights       int       `json:"nights"`
-	TotalPrice   float64   `json:"total_price"`
-	PaidAmount   float64   `json:"paid_amount"`
-	Status       string    `json:"status"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	ID                 uint                          `json:"id"`
+	BookingID          string                        `json:"booking_id"`
+	GuestID            uint                          `json:"guest_id"`
+	RoomID             uint                          `json:"room_id"`
+	CheckInDate        time.Time                     `json:"check_in_date"`
+	CheckOutDate       time.Time                     `json:"check_out_date"`
+	Nights             int                           `json:"nights"`
+	TotalPrice         float64                       `json:"total_price"`
+	PaidAmount         float64                       `json:"paid_amount"`
+	Status             string                        `json:"status"`
+	RatePlan           string                        `json:"rate_plan"`
+	GroupBookingID     *uint                         `json:"group_booking_id"`
+	CancelledAt        *time.Time                    `json:"cancelled_at,omitempty"`
+	CancellationReason string                        `json:"cancellation_reason,omitempty"`
+	RefundAmount       float64                       `json:"refund_amount"`
+	HoldExpiresAt      *time.Time                    `json:"hold_expires_at,omitempty"`
+	Overbooked         bool                          `json:"overbooked"`
+	PromotionCode      string                        `json:"promotion_code,omitempty"`
+	Channel            string                        `json:"channel,omitempty"`
+	DiscountAmount     float64                       `json:"discount_amount"`
+	Discounts          []ReservationDiscountResponse `json:"discounts,omitempty"`
+	Occupants          []ReservationOccupantResponse `json:"occupants,omitempty"`
+	CreatedAt          time.Time                     `json:"created_at"`
+	UpdatedAt          time.Time                     `json:"updated_at"`
+}
+
+type ReservationDiscountResponse struct {
+	ID                 uint    `json:"id"`
+	PromotionID        *uint   `json:"promotion_id"`
+	CorporateAccountID *uint   `json:"corporate_account_id"`
+	Code               string  `json:"code"`
+	Description        string  `json:"description"`
+	DiscountType       string  `json:"discount_type"`
+	Amount             float64 `json:"amount"`
+}
+
+type ReservationOccupantResponse struct {
+	ID           uint       `json:"id"`
+	GuestID      *uint      `json:"guest_id"`
+	IsPrimary    bool       `json:"is_primary"`
+	Name         string     `json:"name"`
+	AgeCategory  string     `json:"age_category"`
+	Nationality  string     `json:"nationality"`
+	IDType       string     `json:"id_type"`
+	IDNumber     string     `json:"id_number"`
+	Verified     bool       `json:"verified"`
+	VerifiedAt   *time.Time `json:"verified_at"`
+	VerifiedByID *uint      `json:"verified_by_id"`
+}
+
+type OccupancyStatusResponse struct {
+	ReservationID  uint                          `json:"reservation_id"`
+	RoomCapacity   int                           `json:"room_capacity"`
+	Occupants      []ReservationOccupantResponse `json:"occupants"`
+	Unverified     []string                      `json:"unverified"` // adults still needing ID verification
+	ReadyToCheckIn bool                          `json:"ready_to_check_in"`
 }
 
 type ReservationDetailResponse struct {
@@ -126,6 +212,435 @@ type ReservationDetailResponse struct {
 	UpdatedAt       time.Time                `json:"updated_at"`
 }
 
+type ReservationChangeResponse struct {
+	ID              uint      `json:"id"`
+	ReservationID   uint      `json:"reservation_id"`
+	ChangeType      string    `json:"change_type"`
+	OldRoomID       uint      `json:"old_room_id"`
+	NewRoomID       uint      `json:"new_room_id"`
+	OldCheckInDate  time.Time `json:"old_check_in_date"`
+	NewCheckInDate  time.Time `json:"new_check_in_date"`
+	OldCheckOutDate time.Time `json:"old_check_out_date"`
+	NewCheckOutDate time.Time `json:"new_check_out_date"`
+	OldNights       int       `json:"old_nights"`
+	NewNights       int       `json:"new_nights"`
+	OldTotalPrice   float64   `json:"old_total_price"`
+	NewTotalPrice   float64   `json:"new_total_price"`
+	PriceDifference float64   `json:"price_difference"`
+	ChangedByID     *uint     `json:"changed_by_id"`
+	Reason          string    `json:"reason"`
+	ChangedAt       time.Time `json:"changed_at"`
+}
+
+type ModifyReservationResponse struct {
+	Reservation ReservationResponse       `json:"reservation"`
+	Change      ReservationChangeResponse `json:"change"`
+}
+
+type WaitlistEntryResponse struct {
+	ID             uint                 `json:"id"`
+	GuestID        uint                 `json:"guest_id"`
+	GuestName      string               `json:"guest_name"`
+	CheckInDate    time.Time            `json:"check_in_date"`
+	CheckOutDate   time.Time            `json:"check_out_date"`
+	RoomTypes      []string             `json:"room_types"`
+	Guests         int                  `json:"guests"`
+	Status         string               `json:"status"`
+	ReservationID  *uint                `json:"reservation_id"`
+	Reservation    *ReservationResponse `json:"reservation,omitempty"` // the held room while offered
+	OfferedAt      *time.Time           `json:"offered_at"`
+	OfferExpiresAt *time.Time           `json:"offer_expires_at"`
+	RespondedAt    *time.Time           `json:"responded_at"`
+	Notes          string               `json:"notes"`
+	CreatedAt      time.Time            `json:"created_at"`
+}
+
+type CancellationPolicyResponse struct {
+	ID                    uint      `json:"id"`
+	Name                  string    `json:"name"`
+	RatePlan              string    `json:"rate_plan"`
+	RoomType              string    `json:"room_type"`
+	NonRefundable         bool      `json:"non_refundable"`
+	FreeCancellationHours int       `json:"free_cancellation_hours"`
+	PenaltyType           string    `json:"penalty_type"`
+	PenaltyPercent        float64   `json:"penalty_percent"`
+	NoShowPenaltyType     string    `json:"no_show_penalty_type"`
+	NoShowPenaltyPercent  float64   `json:"no_show_penalty_percent"`
+	Active                bool      `json:"active"`
+	CreatedAt             time.Time `json:"created_at"`
+}
+
+type CancellationQuoteResponse struct {
+	ReservationID uint       `json:"reservation_id"`
+	PolicyID      *uint      `json:"policy_id"` // nil for the default policy
+	PolicyName    string     `json:"policy_name"`
+	FreeUntil     *time.Time `json:"free_until"`
+	Penalty       float64    `json:"penalty"`
+	Charged       float64    `json:"charged"`
+	Paid          float64    `json:"paid"`
+	Refund        float64    `json:"refund"`
+}
+
+// ===== INVENTORY CALENDAR RESPONSES =====
+
+type OverbookingAllowanceResponse struct {
+	ID       uint      `json:"id"`
+	RoomType string    `json:"room_type"`
+	Date     time.Time `json:"date"`
+	Rooms    int       `json:"rooms"`
+	Notes    string    `json:"notes"`
+}
+
+type InventoryDayResponse struct {
+	Date       time.Time `json:"date"`
+	RoomType   string    `json:"room_type"`
+	Physical   int       `json:"physical"`
+	OutOfOrder int       `json:"out_of_order"`
+	Booked     int       `json:"booked"`
+	GroupHeld  int       `json:"group_held"`
+	Allowance  int       `json:"allowance"`
+	Sellable   int       `json:"sellable"`
+	Oversold   int       `json:"oversold"`
+}
+
+type WalkOutReservationResponse struct {
+	ReservationID uint      `json:"reservation_id"`
+	BookingID     string    `json:"booking_id"`
+	GuestName     string    `json:"guest_name"`
+	GuestEmail    string    `json:"guest_email"`
+	RoomNumber    string    `json:"room_number"`
+	CheckInDate   time.Time `json:"check_in_date"`
+	CheckOutDate  time.Time `json:"check_out_date"`
+	Status        string    `json:"status"`
+	Overbooked    bool      `json:"overbooked"`
+}
+
+type WalkOutNightResponse struct {
+	Date       time.Time                    `json:"date"`
+	RoomType   string                       `json:"room_type"`
+	InService  int                          `json:"in_service"`
+	Booked     int                          `json:"booked"`
+	Oversold   int                          `json:"oversold"`
+	Walk       []WalkOutReservationResponse `json:"walk"`
+	Unresolved int                          `json:"unresolved"`
+}
+
+// ===== RATE PLAN RESPONSES =====
+
+type RatePlanResponse struct {
+	ID                     uint      `json:"id"`
+	Code                   string    `json:"code"`
+	Name                   string    `json:"name"`
+	Description            string    `json:"description"`
+	AdjustmentPercent      float64   `json:"adjustment_percent"`
+	BreakfastIncluded      bool      `json:"breakfast_included"`
+	BreakfastPricePerGuest float64   `json:"breakfast_price_per_guest"`
+	NonRefundable          bool      `json:"non_refundable"`
+	MinNights              int       `json:"min_nights"`
+	OccupancyPricing       bool      `json:"occupancy_pricing"`
+	Active                 bool      `json:"active"`
+	CreatedAt              time.Time `json:"created_at"`
+	UpdatedAt              time.Time `json:"updated_at"`
+}
+
+type RateOverrideResponse struct {
+	ID          uint      `json:"id"`
+	RatePlanID  *uint     `json:"rate_plan_id"`
+	RoomType    string    `json:"room_type"`
+	StartDate   time.Time `json:"start_date"`
+	EndDate     time.Time `json:"end_date"`
+	NightlyRate float64   `json:"nightly_rate"`
+	Reason      string    `json:"reason"`
+}
+
+type OccupancyRateRuleResponse struct {
+	ID                uint    `json:"id"`
+	RatePlanID        *uint   `json:"rate_plan_id"`
+	MinOccupancy      float64 `json:"min_occupancy"`
+	AdjustmentPercent float64 `json:"adjustment_percent"`
+	Active            bool    `json:"active"`
+}
+
+type NightRateResponse struct {
+	Date                time.Time `json:"date"`
+	BaseRate            float64   `json:"base_rate"`
+	PlanAdjustment      float64   `json:"plan_adjustment"`
+	Occupancy           float64   `json:"occupancy"`
+	OccupancyAdjustment float64   `json:"occupancy_adjustment"`
+	Breakfast           float64   `json:"breakfast"`
+	Rate                float64   `json:"rate"`
+}
+
+type StayQuoteResponse struct {
+	RatePlan      string                        `json:"rate_plan"`
+	RoomID        uint                          `json:"room_id"`
+	RoomType      string                        `json:"room_type"`
+	Guests        int                           `json:"guests"`
+	Nights        []NightRateResponse           `json:"nights"`
+	RoomTotal     float64                       `json:"room_total"`
+	Breakfast     float64                       `json:"breakfast"`
+	Discounts     []ReservationDiscountResponse `json:"discounts,omitempty"`
+	DiscountTotal float64                       `json:"discount_total"`
+	Total         float64                       `json:"total"`
+}
+
+type RateCalendarDayResponse struct {
+	NightRateResponse
+	RoomType string `json:"room_type"`
+	Sellable int    `json:"sellable"`
+}
+
+type SimulatedNightResponse struct {
+	Date                  time.Time `json:"date"`
+	CurrentOccupancy      float64   `json:"current_occupancy"`
+	ExpectedOccupancy     float64   `json:"expected_occupancy"`
+	OnTheBooks            float64   `json:"on_the_books"`
+	PickupRooms           int       `json:"pickup_rooms"`
+	PickupRevenue         float64   `json:"pickup_revenue"`
+	BaselinePickupRevenue float64   `json:"baseline_pickup_revenue"`
+}
+
+type RevenueSimulationResponse struct {
+	RatePlan          string                   `json:"rate_plan"`
+	ExpectedOccupancy float64                  `json:"expected_occupancy"`
+	Nights            []SimulatedNightResponse `json:"nights"`
+	OnTheBooks        float64                  `json:"on_the_books"`
+	ProjectedRevenue  float64                  `json:"projected_revenue"`
+	BaselineRevenue   float64                  `json:"baseline_revenue"`
+	Difference        float64                  `json:"difference"`
+}
+
+// ===== PROMOTION RESPONSES =====
+
+type PromotionResponse struct {
+	ID                 uint       `json:"id"`
+	Code               string     `json:"code"`
+	Name               string     `json:"name"`
+	Description        string     `json:"description"`
+	DiscountType       string     `json:"discount_type"`
+	DiscountValue      float64    `json:"discount_value"`
+	BookFrom           *time.Time `json:"book_from"`
+	BookUntil          *time.Time `json:"book_until"`
+	StayFrom           *time.Time `json:"stay_from"`
+	StayUntil          *time.Time `json:"stay_until"`
+	MinNights          int        `json:"min_nights"`
+	RoomTypes          []string   `json:"room_types"`
+	UsageLimit         int        `json:"usage_limit"`
+	UsageCount         int        `json:"usage_count"`
+	CorporateAccountID *uint      `json:"corporate_account_id"`
+	Combinable         bool       `json:"combinable"`
+	Active             bool       `json:"active"`
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+type CorporateAccountResponse struct {
+	ID              uint       `json:"id"`
+	Code            string     `json:"code"`
+	Name            string     `json:"name"`
+	ContactName     string     `json:"contact_name"`
+	ContactEmail    string     `json:"contact_email"`
+	RatePlan        string     `json:"rate_plan"`
+	DiscountPercent float64    `json:"discount_percent"`
+	ContractStart   *time.Time `json:"contract_start"`
+	ContractEnd     *time.Time `json:"contract_end"`
+	Active          bool       `json:"active"`
+	GuestCount      int64      `json:"guest_count"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// ===== SALES CHANNEL RESPONSES =====
+
+type SalesChannelResponse struct {
+	ID            uint       `json:"id"`
+	Code          string     `json:"code"`
+	Name          string     `json:"name"`
+	HotelCode     string     `json:"hotel_code"`
+	RatePlans     []string   `json:"rate_plans"`
+	Currency      string     `json:"currency"`
+	ARIFormat     string     `json:"ari_format"`
+	ARIEndpoint   string     `json:"ari_endpoint"`
+	LastARIPushAt *time.Time `json:"last_ari_push_at"`
+	Active        bool       `json:"active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+}
+
+type ChannelTokenResponse struct {
+	ChannelID uint   `json:"channel_id"`
+	Token     string `json:"token"` // shown once
+}
+
+type ChannelBookingResponse struct {
+	ID                 uint      `json:"id"`
+	ChannelID          uint      `json:"channel_id"`
+	ConfirmationNumber string    `json:"confirmation_number"`
+	ReservationID      uint      `json:"reservation_id"`
+	BookingID          string    `json:"booking_id"`
+	GuestID            uint      `json:"guest_id"`
+	Status             string    `json:"status"`
+	Changed            bool      `json:"changed"` // false when the message was a repeat
+	ImportedAt         time.Time `json:"imported_at"`
+	LastMessageAt      time.Time `json:"last_message_at"`
+}
+
+type ARIRateResponse struct {
+	Guests int     `json:"guests"`
+	Amount float64 `json:"amount"`
+}
+
+type ARIDayResponse struct {
+	Date      time.Time         `json:"date"`
+	RoomType  string            `json:"room_type"`
+	RatePlan  string            `json:"rate_plan"`
+	Available int               `json:"available"`
+	Closed    bool              `json:"closed"`
+	MinNights int               `json:"min_nights"`
+	Rates     []ARIRateResponse `json:"rates"`
+}
+
+type ARIExportResponse struct {
+	Channel     string           `json:"channel"`
+	HotelCode   string           `json:"hotel_code"`
+	Currency    string           `json:"currency"`
+	From        time.Time        `json:"from"`
+	To          time.Time        `json:"to"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Days        []ARIDayResponse `json:"days"`
+}
+
+// ===== CALENDAR RESPONSES =====
+
+type CalendarFeedResponse struct {
+	Kind string `json:"kind"` // room, guest
+	ID   uint   `json:"id"`
+	URL  string `json:"url"` // signed; subscribe to it in a calendar app
+}
+
+// ===== PUBLIC BOOKING RESPONSES =====
+// Returned to unauthenticated website visitors: no guest, room or occupancy data
+
+type PublicNightRateResponse struct {
+	Date      time.Time `json:"date"`
+	Rate      float64   `json:"rate"`
+	Breakfast float64   `json:"breakfast"` // included in the rate
+}
+
+type PublicDiscountResponse struct {
+	Code        string  `json:"code"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+}
+
+type PublicQuoteResponse struct {
+	RoomType      string                    `json:"room_type"`
+	RatePlan      string                    `json:"rate_plan"`
+	RatePlanName  string                    `json:"rate_plan_name"`
+	NonRefundable bool                      `json:"non_refundable"`
+	Guests        int                       `json:"guests"`
+	Nights        []PublicNightRateResponse `json:"nights"`
+	RoomTotal     float64                   `json:"room_total"`
+	Breakfast     float64                   `json:"breakfast"`
+	Discounts     []PublicDiscountResponse  `json:"discounts,omitempty"`
+	DiscountTotal float64                   `json:"discount_total"`
+	Total         float64                   `json:"total"`
+	Deposit       float64                   `json:"deposit"` // due to confirm
+}
+
+type PublicRoomOfferResponse struct {
+	RoomType  string                `json:"room_type"`
+	MaxGuests int                   `json:"max_guests"`
+	Available int                   `json:"available"`
+	Rates     []PublicQuoteResponse `json:"rates"`
+}
+
+type PublicBookingResponse struct {
+	BookingID     string              `json:"booking_id"`
+	Token         string              `json:"token,omitempty"` // shown once, when the booking is made
+	Status        string              `json:"status"`
+	CheckInDate   time.Time           `json:"check_in_date"`
+	CheckOutDate  time.Time           `json:"check_out_date"`
+	Quote         PublicQuoteResponse `json:"quote"`
+	PaidAmount    float64             `json:"paid_amount"`
+	HoldExpiresAt *time.Time          `json:"hold_expires_at,omitempty"`
+}
+
+// ===== GUEST PORTAL RESPONSES =====
+// Returned to a signed-in guest: only their own profile and reservations
+
+type GuestSessionResponse struct {
+	Token     string                     `json:"token"` // shown once; send as Authorization: Bearer
+	ExpiresAt time.Time                  `json:"expires_at"`
+	Guest     GuestPortalProfileResponse `json:"guest"`
+}
+
+type GuestPortalProfileResponse struct {
+	Name          string `json:"name"`
+	Email         string `json:"email"`
+	Phone         string `json:"phone"`
+	Nationality   string `json:"nationality"`
+	LoyaltyTier   string `json:"loyalty_tier"`
+	LoyaltyPoints int    `json:"loyalty_points"`
+}
+
+type GuestPortalReservationResponse struct {
+	BookingID      string                   `json:"booking_id"`
+	Status         string                   `json:"status"`
+	RoomType       string                   `json:"room_type"`
+	CheckInDate    time.Time                `json:"check_in_date"`
+	CheckOutDate   time.Time                `json:"check_out_date"`
+	Nights         int                      `json:"nights"`
+	RatePlan       string                   `json:"rate_plan"`
+	TotalPrice     float64                  `json:"total_price"`
+	PaidAmount     float64                  `json:"paid_amount"`
+	Discounts      []PublicDiscountResponse `json:"discounts,omitempty"`
+	HoldExpiresAt  *time.Time               `json:"hold_expires_at,omitempty"`
+	CancelledAt    *time.Time               `json:"cancelled_at,omitempty"`
+	RefundAmount   float64                  `json:"refund_amount"`
+	PreRegistered  bool                     `json:"pre_registered"` // ID details of every adult are in
+	CanPreRegister bool                     `json:"can_pre_register"`
+}
+
+type GuestStaysResponse struct {
+	Upcoming []GuestPortalReservationResponse `json:"upcoming"`
+	Past     []GuestPortalReservationResponse `json:"past"`
+}
+
+type PreRegisteredOccupantResponse struct {
+	Name        string `json:"name"`
+	IsPrimary   bool   `json:"is_primary"`
+	AgeCategory string `json:"age_category"`
+	Nationality string `json:"nationality"`
+	IDType      string `json:"id_type"`
+	IDNumberEnd string `json:"id_number_end"` // last 4 characters only
+	Verified    bool   `json:"verified"`
+}
+
+type InvoiceLineResponse struct {
+	Date        time.Time `json:"date"`
+	Description string    `json:"description"`
+	Amount      float64   `json:"amount"`
+}
+
+type InvoiceResponse struct {
+	Number       string                `json:"number"`
+	Final        bool                  `json:"final"`
+	IssuedAt     time.Time             `json:"issued_at"`
+	BookingID    string                `json:"booking_id"`
+	GuestName    string                `json:"guest_name"`
+	GuestEmail   string                `json:"guest_email"`
+	RoomType     string                `json:"room_type"`
+	CheckInDate  time.Time             `json:"check_in_date"`
+	CheckOutDate time.Time             `json:"check_out_date"`
+	Nights       int                   `json:"nights"`
+	Lines        []InvoiceLineResponse `json:"lines"`
+	Total        float64               `json:"total"`
+	Paid         float64               `json:"paid"`
+	Refunded     float64               `json:"refunded"`
+	BalanceDue   float64               `json:"balance_due"`
+}
+
 // ===== ROOM RESPONSES =====
 
 type RoomResponse struct {
@@ -143,96 +658,574 @@ type RoomResponse struct {
 // ===== SERVICE REQUEST RESPONSES =====
 
 type ServiceRequestResponse struct {
-	ID            uint       `json:"id"`
-	ReservationID uint       `json:"reservation_id"`
-	GuestID       uint       `json:"guest_id"`
-	ServiceType   string     `json:"service_type"`
-	Status        string     `json:"status"`
-	Priority      string     `json:"priority"`
-	Description   string     `json:"description"`
-	Notes         string     `json:"notes"`
-	AssignedTo    string     `json:"assigned_to"`
-	RequestedAt   time.Time  `json:"requested_at"`
-	CompletedAt   *time.Time `json:"completed_at"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID              uint       `json:"id"`
+	ReservationID   uint       `json:"reservation_id"`
+	GuestID         uint       `json:"guest_id"`
+	ServiceType     string     `json:"service_type"`
+	Status          string     `json:"status"`
+	Priority        string     `json:"priority"`
+	Description     string     `json:"description"`
+	Notes           string     `json:"notes"`
+	AssignedTo      string     `json:"assigned_to"`
+	AssignedStaffID *uint      `json:"assigned_staff_id"`
+	RequestedAt     time.Time  `json:"requested_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 }
 
 type ServiceRequestDetailResponse struct {
-	ID            uint          `json:"id"`
-	ReservationID uint          `json:"reservation_id"`
-	Guest         GuestResponse `json:"guest"`
-	Room          RoomResponse  `json:"room"`
-	ServiceType   string        `json:"service_type"`
-	Status        string        `json:"status"`
-	Priority      string        `json:"priority"`
-	Description   string        `json:"description"`
-	Notes         string        `json:"notes"`
-	AssignedTo    string        `json:"assigned_to"`
-	RequestedAt   time.Time     `json:"requested_at"`
-	CompletedAt   *time.Time    `json:"completed_at"`
-	CreatedAt     time.Time     `json:"created_at"`
-	UpdatedAt     time.Time     `json:"updated_at"`
+	ID              uint          `json:"id"`
+	ReservationID   uint          `json:"reservation_id"`
+	Guest           GuestResponse `json:"guest"`
+	Room            RoomResponse  `json:"room"`
+	ServiceType     string        `json:"service_type"`
+	Status          string        `json:"status"`
+	Priority        string        `json:"priority"`
+	Description     string        `json:"description"`
+	Notes           string        `json:"notes"`
+	AssignedTo      string        `json:"assigned_to"`
+	AssignedStaffID *uint         `json:"assigned_staff_id"`
+	RequestedAt     time.Time     `json:"requested_at"`
+	CompletedAt     *time.Time    `json:"completed_at"`
+	CreatedAt       time.Time     `json:"created_at"`
+	UpdatedAt       time.Time     `json:"updated_at"`
 }
 
 // ===== ROOM SERVICE ORDER RESPONSES =====
 
 type RoomServiceOrderItemResponse struct {
-	ID       uint    `json:"id"`
-	Name     string  `json:"name"`
-	Price    float64 `json:"price"`
-	Quantity int     `json:"quantity"`
+	ID         uint    `json:"id"`
+	MenuItemID uint    `json:"menu_item_id"`
+	Name       string  `json:"name"`
+	Price      float64 `json:"price"`
+	Quantity   int     `json:"quantity"`
+	LineTotal  float64 `json:"line_total"`
 }
 
 type RoomServiceOrderResponse struct {
-	ID            uint                           `json:"id"`
-	OrderID       string                         `json:"order_id"`
-	ReservationID uint                           `json:"reservation_id"`
-	GuestID       uint                           `json:"guest_id"`
-	Items         []RoomServiceOrderItemResponse `json:"items"`
-	Subtotal      float64                        `json:"subtotal"`
-	DeliveryFee   float64                        `json:"delivery_fee"`
-	Total         float64                        `json:"total"`
-	Status        string                         `json:"status"`
-	SpecialNotes  string                         `json:"special_notes"`
-	OrderedAt     time.Time                      `json:"ordered_at"`
-	DeliveredAt   *time.Time                     `json:"delivered_at"`
-	CreatedAt     time.Time                      `json:"created_at"`
-	UpdatedAt     time.Time                      `json:"updated_at"`
+	ID                  uint                           `json:"id"`
+	OrderID             string                         `json:"order_id"`
+	ReservationID       uint                           `json:"reservation_id"`
+	GuestID             uint                           `json:"guest_id"`
+	Items               []RoomServiceOrderItemResponse `json:"items"`
+	Subtotal            float64                        `json:"subtotal"`
+	DeliveryFee         float64                        `json:"delivery_fee"`
+	ServiceCharge       float64                        `json:"service_charge"`
+	VAT                 float64                        `json:"vat"`
+	Total               float64                        `json:"total"`
+	Status              string                         `json:"status"`
+	SpecialNotes        string                         `json:"special_notes"`
+	OrderedAt           time.Time                      `json:"ordered_at"`
+	AcceptedAt          *time.Time                     `json:"accepted_at"`
+	ReadyAt             *time.Time                     `json:"ready_at"`
+	DispatchedAt        *time.Time                     `json:"dispatched_at"`
+	EstimatedDeliveryAt *time.Time                     `json:"estimated_delivery_at"`
+	ETAMinutes          int                            `json:"eta_minutes"`
+	DeliveredAt         *time.Time                     `json:"delivered_at"`
+	CreatedAt           time.Time                      `json:"created_at"`
+	UpdatedAt           time.Time                      `json:"updated_at"`
+}
+
+// ===== KITCHEN DISPLAY RESPONSES =====
+
+type KitchenStationResponse struct {
+	ID                 uint     `json:"id"`
+	Name               string   `json:"name"`
+	Categories         []string `json:"categories"`
+	DefaultPrepMinutes int      `json:"default_prep_minutes"`
+	EstimatedPrepMins  int      `json:"estimated_prep_mins"`
+	Capacity           int      `json:"capacity"`
+	Active             bool     `json:"active"`
+}
+
+type KitchenTicketResponse struct {
+	ID                 uint                           `json:"id"`
+	RoomServiceOrderID uint                           `json:"room_service_order_id"`
+	OrderID            string                         `json:"order_id"`
+	RoomNumber         string                         `json:"room_number"`
+	StationID          uint                           `json:"station_id"`
+	StationName        string                         `json:"station_name"`
+	Status             string                         `json:"status"`
+	Items              []RoomServiceOrderItemResponse `json:"items"`
+	SpecialNotes       string                         `json:"special_notes"`
+	EstimatedPrepMins  int                            `json:"estimated_prep_mins"`
+	QueuedAt           time.Time                      `json:"queued_at"`
+	AcceptedAt         *time.Time                     `json:"accepted_at"`
+	CookingAt          *time.Time                     `json:"cooking_at"`
+	ReadyAt            *time.Time                     `json:"ready_at"`
+}
+
+type KitchenQueueResponse struct {
+	Station KitchenStationResponse  `json:"station"`
+	Tickets []KitchenTicketResponse `json:"tickets"`
+}
+
+// ===== FOLIO RESPONSES =====
+
+type FolioChargeResponse struct {
+	ID            uint      `json:"id"`
+	ReservationID uint      `json:"reservation_id"`
+	GuestID       uint      `json:"guest_id"`
+	ChargeType    string    `json:"charge_type"`
+	Description   string    `json:"description"`
+	Amount        float64   `json:"amount"`
+	SourceType    string    `json:"source_type"`
+	SourceID      uint      `json:"source_id"`
+	PostedAt      time.Time `json:"posted_at"`
+}
+
+type FolioResponse struct {
+	ReservationID uint                  `json:"reservation_id"`
+	Charges       []FolioChargeResponse `json:"charges"`
+	TotalCharges  float64               `json:"total_charges"`
+	PaidAmount    float64               `json:"paid_amount"`
+	Balance       float64               `json:"balance"`
+}
+
+// ===== GROUP BOOKING RESPONSES =====
+
+type GroupBookingResponse struct {
+	ID             uint                     `json:"id"`
+	Code           string                   `json:"code"`
+	Name           string                   `json:"name"`
+	GroupType      string                   `json:"group_type"`
+	ContactGuestID *uint                    `json:"contact_guest_id"`
+	ContactName    string                   `json:"contact_name"`
+	ContactEmail   string                   `json:"contact_email"`
+	CheckInDate    time.Time                `json:"check_in_date"`
+	CheckOutDate   time.Time                `json:"check_out_date"`
+	ReleaseDate    time.Time                `json:"release_date"`
+	MasterPaysRoom bool                     `json:"master_pays_room"`
+	Status         string                   `json:"status"`
+	ReleasedAt     *time.Time               `json:"released_at"`
+	Allotments     []GroupAllotmentResponse `json:"allotments"`
+	RoomsHeld      int                      `json:"rooms_held"`
+	RoomsPickedUp  int                      `json:"rooms_picked_up"`
+	Notes          string                   `json:"notes"`
+	CreatedAt      time.Time                `json:"created_at"`
+	UpdatedAt      time.Time                `json:"updated_at"`
+}
+
+type GroupAllotmentResponse struct {
+	RoomType     string  `json:"room_type"`
+	Rooms        int     `json:"rooms"`
+	PickedUp     int     `json:"picked_up"`
+	Remaining    int     `json:"remaining"` // zero once the group is released
+	RatePerNight float64 `json:"rate_per_night"`
+}
+
+type GroupFolioChargeResponse struct {
+	ID            uint      `json:"id"`
+	ReservationID *uint     `json:"reservation_id"`
+	ChargeType    string    `json:"charge_type"`
+	Description   string    `json:"description"`
+	Amount        float64   `json:"amount"`
+	SourceType    string    `json:"source_type"`
+	SourceID      uint      `json:"source_id"`
+	PostedAt      time.Time `json:"posted_at"`
+}
+
+type GroupFolioResponse struct {
+	GroupBookingID uint                       `json:"group_booking_id"`
+	Charges        []GroupFolioChargeResponse `json:"charges"`
+	TotalCharges   float64                    `json:"total_charges"`
+}
+
+type RoomingListImportResponse struct {
+	Created  int                         `json:"created"`
+	Existing int                         `json:"existing"`
+	Failed   int                         `json:"failed"`
+	Results  []RoomingListResultResponse `json:"results"`
+}
+
+type RoomingListResultResponse struct {
+	Row           int    `json:"row"`
+	Email         string `json:"email"`
+	ReservationID uint   `json:"reservation_id,omitempty"`
+	BookingID     string `json:"booking_id,omitempty"`
+	RoomNumber    string `json:"room_number,omitempty"`
+	Existing      bool   `json:"existing"`
+	Error         string `json:"error,omitempty"`
 }
 
 // ===== HOUSEKEEPING RESPONSES =====
 
 type HousekeepingRequestResponse struct {
-	ID            uint       `json:"id"`
-	ReservationID uint       `json:"reservation_id"`
-	GuestID       uint       `json:"guest_id"`
-	RequestType   string     `json:"request_type"`
-	Description   string     `json:"description"`
-	ScheduleTime  string     `json:"schedule_time"`
-	Status        string     `json:"status"`
-	AssignedTo    string     `json:"assigned_to"`
-	RequestedAt   time.Time  `json:"requested_at"`
-	CompletedAt   *time.Time `json:"completed_at"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID              uint       `json:"id"`
+	ReservationID   uint       `json:"reservation_id"`
+	GuestID         uint       `json:"guest_id"`
+	RequestType     string     `json:"request_type"`
+	Description     string     `json:"description"`
+	ScheduleTime    string     `json:"schedule_time"`
+	Status          string     `json:"status"`
+	AssignedTo      string     `json:"assigned_to"`
+	AssignedStaffID *uint      `json:"assigned_staff_id"`
+	TaskID          *uint      `json:"task_id"`
+	RequestedAt     time.Time  `json:"requested_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+type HousekeepingTaskResponse struct {
+	ID                    uint       `json:"id"`
+	RoomID                uint       `json:"room_id"`
+	RoomNumber            string     `json:"room_number"`
+	Floor                 int        `json:"floor"`
+	ReservationID         *uint      `json:"reservation_id"`
+	HousekeepingRequestID *uint      `json:"housekeeping_request_id"`
+	TaskType              string     `json:"task_type"`
+	Status                string     `json:"status"`
+	TaskDate              time.Time  `json:"task_date"`
+	WindowStart           time.Time  `json:"window_start"`
+	WindowEnd             time.Time  `json:"window_end"`
+	EstimatedMinutes      int        `json:"estimated_minutes"`
+	AssignedStaffID       *uint      `json:"assigned_staff_id"`
+	AssignedStaffName     string     `json:"assigned_staff_name"`
+	StartedAt             *time.Time `json:"started_at"`
+	CleanedAt             *time.Time `json:"cleaned_at"`
+	InspectedAt           *time.Time `json:"inspected_at"`
+	Notes                 string     `json:"notes"`
+}
+
+type HousekeeperScheduleResponse struct {
+	StaffID      uint                       `json:"staff_id"`
+	StaffName    string                     `json:"staff_name"`
+	TotalMinutes int                        `json:"total_minutes"`
+	Floors       []int                      `json:"floors"`
+	Tasks        []HousekeepingTaskResponse `json:"tasks"`
+}
+
+type HousekeepingScheduleResponse struct {
+	Date         time.Time                     `json:"date"`
+	Housekeepers []HousekeeperScheduleResponse `json:"housekeepers"`
+	Unassigned   []HousekeepingTaskResponse    `json:"unassigned"`
+}
+
+// ===== ROOM INSPECTION RESPONSES =====
+
+type InspectionChecklistItemResponse struct {
+	ID        uint   `json:"id"`
+	Label     string `json:"label"`
+	Area      string `json:"area"`
+	Weight    int    `json:"weight"`
+	Critical  bool   `json:"critical"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type InspectionChecklistResponse struct {
+	ID        uint                              `json:"id"`
+	RoomType  string                            `json:"room_type"`
+	Name      string                            `json:"name"`
+	PassScore float64                           `json:"pass_score"`
+	Active    bool                              `json:"active"`
+	Items     []InspectionChecklistItemResponse `json:"items"`
+	CreatedAt time.Time                         `json:"created_at"`
+	UpdatedAt time.Time                         `json:"updated_at"`
+}
+
+type RoomInspectionResultResponse struct {
+	ChecklistItemID uint   `json:"checklist_item_id"`
+	Label           string `json:"label"`
+	Weight          int    `json:"weight"`
+	Critical        bool   `json:"critical"`
+	Passed          bool   `json:"passed"`
+	Notes           string `json:"notes"`
+}
+
+type RoomInspectionPhotoResponse struct {
+	ID              uint   `json:"id"`
+	ChecklistItemID *uint  `json:"checklist_item_id"`
+	URL             string `json:"url"`
+	Caption         string `json:"caption"`
+}
+
+type RoomInspectionResponse struct {
+	ID                 uint                           `json:"id"`
+	RoomID             uint                           `json:"room_id"`
+	RoomNumber         string                         `json:"room_number"`
+	HousekeepingTaskID uint                           `json:"housekeeping_task_id"`
+	ChecklistID        uint                           `json:"checklist_id"`
+	InspectorID        uint                           `json:"inspector_id"`
+	HousekeeperID      *uint                          `json:"housekeeper_id"`
+	Result             string                         `json:"result"`
+	Score              float64                        `json:"score"`
+	Notes              string                         `json:"notes"`
+	RequeuedRequestID  *uint                          `json:"requeued_request_id"`
+	Results            []RoomInspectionResultResponse `json:"results"`
+	Photos             []RoomInspectionPhotoResponse  `json:"photos"`
+	InspectedAt        time.Time                      `json:"inspected_at"`
+}
+
+type HousekeeperInspectionScoreResponse struct {
+	HousekeeperID   uint    `json:"housekeeper_id"`
+	HousekeeperName string  `json:"housekeeper_name"`
+	Inspections     int64   `json:"inspections"`
+	Passed          int64   `json:"passed"`
+	PassRate        float64 `json:"pass_rate"`
+	AverageScore    float64 `json:"average_score"`
 }
 
 // ===== MAINTENANCE RESPONSES =====
 
 type MaintenanceIssueResponse struct {
-	ID            uint       `json:"id"`
-	ReservationID uint       `json:"reservation_id"`
-	GuestID       uint       `json:"guest_id"`
-	IssueType     string     `json:"issue_type"`
-	Description   string     `json:"description"`
-	Status        string     `json:"status"`
-	Priority      string     `json:"priority"`
-	AssignedTo    string     `json:"assigned_to"`
-	ReportedAt    time.Time  `json:"reported_at"`
-	ResolvedAt    *time.Time `json:"resolved_at"`
-	CreatedAt     time.Time  `json:"created_at"`
-	UpdatedAt     time.Time  `json:"updated_at"`
+	ID              uint                         `json:"id"`
+	ReservationID   uint                         `json:"reservation_id"`
+	GuestID         uint                         `json:"guest_id"`
+	RoomID          uint                         `json:"room_id"`
+	AssetID         *uint                        `json:"asset_id"`
+	IssueType       string                       `json:"issue_type"`
+	Description     string                       `json:"description"`
+	Status          string                       `json:"status"`
+	Priority        string                       `json:"priority"`
+	AssignedTo      string                       `json:"assigned_to"`
+	AssignedStaffID *uint                        `json:"assigned_staff_id"`
+	ReportedAt      time.Time                    `json:"reported_at"`
+	AcknowledgedAt  *time.Time                   `json:"acknowledged_at"`
+	ResolvedAt      *time.Time                   `json:"resolved_at"`
+	SLA             MaintenanceSLAStatusResponse `json:"sla"`
+	RoomOutOfOrder  bool                         `json:"room_out_of_order"`
+	RoomMoves       []RoomMoveProposalResponse   `json:"room_moves,omitempty"`
+	CreatedAt       time.Time                    `json:"created_at"`
+	UpdatedAt       time.Time                    `json:"updated_at"`
+}
+
+type MaintenanceSLAStatusResponse struct {
+	Status                string     `json:"status"` // on-track, at-risk, breached, met
+	AcknowledgeDueAt      *time.Time `json:"acknowledge_due_at"`
+	ResolveDueAt          *time.Time `json:"resolve_due_at"`
+	TimeToAcknowledgeMins *float64   `json:"time_to_acknowledge_mins"`
+	TimeToResolveMins     *float64   `json:"time_to_resolve_mins"`
+	EscalationLevel       int        `json:"escalation_level"`
+	EscalatedAt           *time.Time `json:"escalated_at"`
+	EscalatedToID         *uint      `json:"escalated_to_id"`
+}
+
+type RoomMoveProposalResponse struct {
+	ID                 uint       `json:"id"`
+	MaintenanceIssueID uint       `json:"maintenance_issue_id"`
+	ReservationID      uint       `json:"reservation_id"`
+	BookingID          string     `json:"booking_id"`
+	GuestName          string     `json:"guest_name"`
+	CheckInDate        time.Time  `json:"check_in_date"`
+	CheckOutDate       time.Time  `json:"check_out_date"`
+	FromRoomID         uint       `json:"from_room_id"`
+	FromRoomNumber     string     `json:"from_room_number"`
+	ToRoomID           *uint      `json:"to_room_id"`
+	ToRoomNumber       string     `json:"to_room_number"`
+	Status             string     `json:"status"` // proposed, accepted, rejected, no-alternative, withdrawn
+	DecidedAt          *time.Time `json:"decided_at"`
+}
+
+type MaintenanceSLAResponse struct {
+	ID                 uint   `json:"id"`
+	IssueType          string `json:"issue_type"`
+	Priority           string `json:"priority"`
+	AcknowledgeMinutes int    `json:"acknowledge_minutes"`
+	ResolveMinutes     int    `json:"resolve_minutes"`
+}
+
+type MaintenanceEscalationResponse struct {
+	ID                 uint      `json:"id"`
+	MaintenanceIssueID uint      `json:"maintenance_issue_id"`
+	Level              int       `json:"level"`
+	Reason             string    `json:"reason"`
+	EscalatedToID      *uint     `json:"escalated_to_id"`
+	EscalatedToName    string    `json:"escalated_to_name"`
+	DueAt              time.Time `json:"due_at"`
+	EscalatedAt        time.Time `json:"escalated_at"`
+}
+
+type MaintenanceSLAMetricsResponse struct {
+	From       time.Time                    `json:"from"`
+	To         time.Time                    `json:"to"`
+	ByPriority []MaintenanceSLAMetricsEntry `json:"by_priority"`
+}
+
+type MaintenanceSLAMetricsEntry struct {
+	Priority              string  `json:"priority"`
+	Issues                int     `json:"issues"`
+	Acknowledged          int     `json:"acknowledged"`
+	Resolved              int     `json:"resolved"`
+	AvgAcknowledgeMinutes float64 `json:"avg_acknowledge_minutes"`
+	AvgResolveMinutes     float64 `json:"avg_resolve_minutes"`
+	AcknowledgeBreaches   int     `json:"acknowledge_breaches"`
+	ResolveBreaches       int     `json:"resolve_breaches"`
+	CompliancePercent     float64 `json:"compliance_percent"`
+}
+
+// ===== STAFF WORKLOAD RESPONSES =====
+
+type WorkloadItemResponse struct {
+	Type          string    `json:"type"` // service-request, housekeeping-request, maintenance-issue
+	ID            uint      `json:"id"`
+	ReservationID uint      `json:"reservation_id"`
+	Kind          string    `json:"kind"`
+	Status        string    `json:"status"`
+	Priority      string    `json:"priority"`
+	Description   string    `json:"description"`
+	RequestedAt   time.Time `json:"requested_at"`
+	AgeMinutes    int       `json:"age_minutes"`
+}
+
+type StaffWorkloadResponse struct {
+	StaffID              uint                   `json:"staff_id"`
+	Name                 string                 `json:"name"`
+	Role                 string                 `json:"role"`
+	ServiceRequests      int                    `json:"service_requests"`
+	HousekeepingRequests int                    `json:"housekeeping_requests"`
+	MaintenanceIssues    int                    `json:"maintenance_issues"`
+	Total                int                    `json:"total"`
+	OldestRequestedAt    *time.Time             `json:"oldest_requested_at"`
+	Items                []WorkloadItemResponse `json:"items,omitempty"`
+}
+
+// ===== TASK INBOX RESPONSES =====
+
+type InboxTaskResponse struct {
+	Type              string    `json:"type"` // service-request, housekeeping-request, maintenance-issue
+	ID                uint      `json:"id"`
+	ReservationID     uint      `json:"reservation_id"`
+	GuestID           uint      `json:"guest_id"`
+	RoomID            *uint     `json:"room_id"`
+	RoomNumber        string    `json:"room_number"`
+	Kind              string    `json:"kind"`
+	Role              string    `json:"role"`
+	Priority          string    `json:"priority"`
+	Status            string    `json:"status"` // open, in-progress, done, cancelled
+	SourceStatus      string    `json:"source_status"`
+	AssignedStaffID   *uint     `json:"assigned_staff_id"`
+	AssignedStaffName string    `json:"assigned_staff_name"`
+	Description       string    `json:"description"`
+	RequestedAt       time.Time `json:"requested_at"`
+	AgeMinutes        int       `json:"age_minutes"`
+}
+
+// ===== STAFF SHIFT RESPONSES =====
+
+type StaffShiftResponse struct {
+	ID             uint       `json:"id"`
+	StaffID        uint       `json:"staff_id"`
+	StaffName      string     `json:"staff_name"`
+	Role           string     `json:"role"`
+	ShiftDate      time.Time  `json:"shift_date"`
+	StartsAt       time.Time  `json:"starts_at"`
+	EndsAt         time.Time  `json:"ends_at"`
+	BreakMinutes   int        `json:"break_minutes"`
+	Unplanned      bool       `json:"unplanned"`
+	ClockInAt      *time.Time `json:"clock_in_at"`
+	ClockOutAt     *time.Time `json:"clock_out_at"`
+	Status         string     `json:"status"`
+	ScheduledHours float64    `json:"scheduled_hours"`
+	WorkedHours    float64    `json:"worked_hours"`
+	Notes          string     `json:"notes"`
+}
+
+type OnDutyStaffResponse struct {
+	StaffID   uint      `json:"staff_id"`
+	Name      string    `json:"name"`
+	Role      string    `json:"role"`
+	Phone     string    `json:"phone"`
+	ClockInAt time.Time `json:"clock_in_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+type StaffHoursReportResponse struct {
+	PeriodStart time.Time         `json:"period_start"`
+	PeriodEnd   time.Time         `json:"period_end"`
+	Staff       []StaffHoursEntry `json:"staff"`
+}
+
+type StaffHoursEntry struct {
+	StaffID        uint    `json:"staff_id"`
+	Name           string  `json:"name"`
+	Role           string  `json:"role"`
+	Shifts         int     `json:"shifts"`
+	MissedShifts   int     `json:"missed_shifts"`
+	ScheduledHours float64 `json:"scheduled_hours"`
+	WorkedHours    float64 `json:"worked_hours"`
+	RegularHours   float64 `json:"regular_hours"`
+	OvertimeHours  float64 `json:"overtime_hours"`
+}
+
+type AssigneeMigrationResponse struct {
+	Table     string   `json:"table"`
+	Matched   int64    `json:"matched"`
+	Unmatched []string `json:"unmatched"`
+}
+
+// ===== ROOM ASSET RESPONSES =====
+
+type RoomAssetResponse struct {
+	ID           uint       `json:"id"`
+	RoomID       uint       `json:"room_id"`
+	RoomNumber   string     `json:"room_number"`
+	AssetType    string     `json:"asset_type"`
+	Name         string     `json:"name"`
+	Manufacturer string     `json:"manufacturer"`
+	ModelNumber  string     `json:"model_number"`
+	SerialNumber string     `json:"serial_number"`
+	InstalledAt  *time.Time `json:"installed_at"`
+	Status       string     `json:"status"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+type PreventiveMaintenanceScheduleResponse struct {
+	ID               uint       `json:"id"`
+	AssetID          uint       `json:"asset_id"`
+	Task             string     `json:"task"`
+	IntervalDays     int        `json:"interval_days"`
+	ToleranceDays    int        `json:"tolerance_days"`
+	EstimatedMinutes int        `json:"estimated_minutes"`
+	LastPerformedAt  *time.Time `json:"last_performed_at"`
+	NextDueAt        time.Time  `json:"next_due_at"`
+	Active           bool       `json:"active"`
+}
+
+type MaintenanceWorkOrderResponse struct {
+	ID               uint       `json:"id"`
+	AssetID          uint       `json:"asset_id"`
+	AssetType        string     `json:"asset_type"`
+	AssetName        string     `json:"asset_name"`
+	RoomID           uint       `json:"room_id"`
+	RoomNumber       string     `json:"room_number"`
+	ScheduleID       *uint      `json:"schedule_id"`
+	Task             string     `json:"task"`
+	Status           string     `json:"status"`
+	DueAt            time.Time  `json:"due_at"`
+	ScheduledFor     *time.Time `json:"scheduled_for"`
+	EstimatedMinutes int        `json:"estimated_minutes"`
+	StartedAt        *time.Time `json:"started_at"`
+	CompletedAt      *time.Time `json:"completed_at"`
+	Notes            string     `json:"notes"`
+}
+
+type AssetHistoryResponse struct {
+	Asset                     RoomAssetResponse              `json:"asset"`
+	Breakdowns                int                            `json:"breakdowns"`
+	LastBreakdownAt           *time.Time                     `json:"last_breakdown_at"`
+	MeanDaysBetweenBreakdowns *float64                       `json:"mean_days_between_breakdowns"`
+	BreakdownsPerYear         float64                        `json:"breakdowns_per_year"`
+	PreventiveCompleted       int                            `json:"preventive_completed"`
+	LastServicedAt            *time.Time                     `json:"last_serviced_at"`
+	Issues                    []MaintenanceIssueResponse     `json:"issues"`
+	WorkOrders                []MaintenanceWorkOrderResponse `json:"work_orders"`
+}
+
+type AssetBreakdownReportResponse struct {
+	From   time.Time                  `json:"from"`
+	To     time.Time                  `json:"to"`
+	Assets []AssetBreakdownCountEntry `json:"assets"`
+}
+
+type AssetBreakdownCountEntry struct {
+	AssetID    uint   `json:"asset_id"`
+	RoomID     uint   `json:"room_id"`
+	RoomNumber string `json:"room_number"`
+	AssetType  string `json:"asset_type"`
+	Breakdowns int64  `json:"breakdowns"`
 }
 
 // ===== MENU ITEM RESPONSES =====
@@ -244,10 +1237,61 @@ type MenuItemResponse struct {
 	Price       float64   `json:"price"`
 	Category    string    `json:"category"`
 	Available   bool      `json:"available"`
+	OutOfStock  bool      `json:"out_of_stock"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
 
+// ===== INVENTORY RESPONSES =====
+
+type IngredientResponse struct {
+	ID                uint      `json:"id"`
+	Name              string    `json:"name"`
+	Unit              string    `json:"unit"`
+	StockQuantity     float64   `json:"stock_quantity"`
+	LowStockThreshold float64   `json:"low_stock_threshold"`
+	LowStock          bool      `json:"low_stock"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+type RecipeItemResponse struct {
+	IngredientID   uint    `json:"ingredient_id"`
+	IngredientName string  `json:"ingredient_name"`
+	Unit           string  `json:"unit"`
+	Quantity       float64 `json:"quantity"`
+}
+
+type MenuItemRecipeResponse struct {
+	MenuItemID uint                 `json:"menu_item_id"`
+	Name       string               `json:"name"`
+	Items      []RecipeItemResponse `json:"items"`
+}
+
+type StockMovementResponse struct {
+	ID           uint      `json:"id"`
+	IngredientID uint      `json:"ingredient_id"`
+	Change       float64   `json:"change"`
+	BalanceAfter float64   `json:"balance_after"`
+	Reason       string    `json:"reason"`
+	SourceType   string    `json:"source_type"`
+	SourceID     uint      `json:"source_id"`
+	Notes        string    `json:"notes"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type LowStockAlertResponse struct {
+	ID             uint       `json:"id"`
+	IngredientID   uint       `json:"ingredient_id"`
+	IngredientName string     `json:"ingredient_name"`
+	Unit           string     `json:"unit"`
+	StockQuantity  float64    `json:"stock_quantity"`
+	Threshold      float64    `json:"threshold"`
+	Status         string     `json:"status"`
+	RaisedAt       time.Time  `json:"raised_at"`
+	ResolvedAt     *time.Time `json:"resolved_at"`
+}
+
 // ===== CHECK-IN/CHECK-OUT RESPONSES =====
 
 type CheckInResponse struct {
@@ -273,6 +1317,8 @@ type CheckOutResponse struct {
 	RoomCondition string    `json:"room_condition"`
 	Charges       float64   `json:"charges"`
 	Notes         string    `json:"notes"`
+	PointsEarned  int       `json:"points_earned"`
+	LoyaltyTier   string    `json:"loyalty_tier"`
 	CreatedAt     time.Time `json:"created_at"`
 	UpdatedAt     time.Time `json:"updated_at"`
 }
@@ -345,11 +1391,370 @@ type CreateGuestRequest struct {
 }
 
 type CreateReservationRequest struct {
+	GuestID       uint      `json:"guest_id" binding:"required"`
+	RoomID        uint      `json:"room_id" binding:"required"`
+	CheckInDate   time.Time `json:"check_in_date" binding:"required"`
+	CheckOutDate  time.Time `json:"check_out_date" binding:"required"`
+	RatePlan      string    `json:"rate_plan"`                        // defaults to BAR
+	Guests        int       `json:"guests" binding:"omitempty,min=1"` // for breakfast plans
+	PromotionCode string    `json:"promotion_code"`
+	TotalPrice    float64   `json:"total_price"` // priced from the rate plan if omitted
+}
+
+type QuoteStayRequest struct {
+	RoomID        uint      `json:"room_id" binding:"required"`
+	CheckInDate   time.Time `json:"check_in_date" binding:"required"`
+	CheckOutDate  time.Time `json:"check_out_date" binding:"required,gtfield=CheckInDate"`
+	RatePlan      string    `json:"rate_plan"`
+	Guests        int       `json:"guests" binding:"omitempty,min=1"`
+	GuestID       uint      `json:"guest_id"` // applies the guest's corporate discount
+	PromotionCode string    `json:"promotion_code"`
+}
+
+type ModifyReservationRequest struct {
+	CheckInDate  *time.Time `json:"check_in_date"`
+	CheckOutDate *time.Time `json:"check_out_date"`
+	RoomID       *uint      `json:"room_id"`
+	StaffID      *uint      `json:"staff_id"`
+	Reason       string     `json:"reason"`
+}
+
+type EarlyDepartureRequest struct {
+	CheckOutDate *time.Time `json:"check_out_date"` // defaults to today
+	StaffID      *uint      `json:"staff_id"`
+	Reason       string     `json:"reason"`
+}
+
+type SetOverbookingAllowanceRequest struct {
+	RoomType string    `json:"room_type" binding:"required,oneof=Standard Deluxe Suite"`
+	From     time.Time `json:"from" binding:"required"`
+	To       time.Time `json:"to" binding:"required,gtfield=From"`
+	Rooms    int       `json:"rooms" binding:"min=0"`
+	Notes    string    `json:"notes"`
+}
+
+type CreateRatePlanRequest struct {
+	Code                   string  `json:"code" binding:"required,alphanum,max=10"`
+	Name                   string  `json:"name" binding:"required"`
+	Description            string  `json:"description"`
+	AdjustmentPercent      float64 `json:"adjustment_percent" binding:"min=-100"`
+	BreakfastIncluded      bool    `json:"breakfast_included"`
+	BreakfastPricePerGuest float64 `json:"breakfast_price_per_guest" binding:"min=0"`
+	NonRefundable          bool    `json:"non_refundable"`
+	MinNights              int     `json:"min_nights" binding:"min=0"`
+	OccupancyPricing       *bool   `json:"occupancy_pricing"` // defaults to true
+}
+
+type UpdateRatePlanRequest struct {
+	Name                   string   `json:"name"`
+	Description            *string  `json:"description"`
+	AdjustmentPercent      *float64 `json:"adjustment_percent" binding:"omitempty,min=-100"`
+	BreakfastIncluded      *bool    `json:"breakfast_included"`
+	BreakfastPricePerGuest *float64 `json:"breakfast_price_per_guest" binding:"omitempty,min=0"`
+	NonRefundable          *bool    `json:"non_refundable"`
+	MinNights              *int     `json:"min_nights" binding:"omitempty,min=0"`
+	OccupancyPricing       *bool    `json:"occupancy_pricing"`
+	Active                 *bool    `json:"active"`
+}
+
+type CreateRateOverrideRequest struct {
+	RatePlanID  *uint     `json:"rate_plan_id"`
+	RoomType    string    `json:"room_type" binding:"required,oneof=Standard Deluxe Suite"`
+	StartDate   time.Time `json:"start_date" binding:"required"`
+	EndDate     time.Time `json:"end_date" binding:"required,gtefield=StartDate"`
+	NightlyRate float64   `json:"nightly_rate" binding:"required,gt=0"`
+	Reason      string    `json:"reason"`
+}
+
+type OccupancyRateRuleRequest struct {
+	RatePlanID        *uint   `json:"rate_plan_id"`
+	MinOccupancy      float64 `json:"min_occupancy" binding:"min=0,max=100"`
+	AdjustmentPercent float64 `json:"adjustment_percent" binding:"min=-100"`
+}
+
+type SimulateRevenueRequest struct {
+	RatePlan          string                     `json:"rate_plan"`
+	From              time.Time                  `json:"from" binding:"required"`
+	To                time.Time                  `json:"to" binding:"required,gtfield=From"`
+	ExpectedOccupancy float64                    `json:"expected_occupancy" binding:"required,gt=0,max=100"`
+	Rules             []OccupancyRateRuleRequest `json:"rules" binding:"omitempty,dive"` // omit to use the active rules
+}
+
+type CreatePromotionRequest struct {
+	Code               string     `json:"code" binding:"required,alphanum,max=20"`
+	Name               string     `json:"name" binding:"required"`
+	Description        string     `json:"description"`
+	DiscountType       string     `json:"discount_type" binding:"required,oneof=percent fixed per-night free-nights"`
+	DiscountValue      float64    `json:"discount_value" binding:"required,gt=0"`
+	BookFrom           *time.Time `json:"book_from"`
+	BookUntil          *time.Time `json:"book_until"`
+	StayFrom           *time.Time `json:"stay_from"`
+	StayUntil          *time.Time `json:"stay_until"`
+	MinNights          int        `json:"min_nights" binding:"min=0"`
+	RoomTypes          []string   `json:"room_types" binding:"omitempty,dive,oneof=Standard Deluxe Suite"`
+	UsageLimit         int        `json:"usage_limit" binding:"min=0"`
+	CorporateAccountID *uint      `json:"corporate_account_id"`
+	Combinable         bool       `json:"combinable"`
+}
+
+type UpdatePromotionRequest struct {
+	Name          string     `json:"name"`
+	Description   *string    `json:"description"`
+	DiscountValue *float64   `json:"discount_value" binding:"omitempty,gt=0"`
+	BookFrom      *time.Time `json:"book_from"`
+	BookUntil     *time.Time `json:"book_until"`
+	StayFrom      *time.Time `json:"stay_from"`
+	StayUntil     *time.Time `json:"stay_until"`
+	MinNights     *int       `json:"min_nights" binding:"omitempty,min=0"`
+	RoomTypes     []string   `json:"room_types" binding:"omitempty,dive,oneof=Standard Deluxe Suite"`
+	UsageLimit    *int       `json:"usage_limit" binding:"omitempty,min=0"`
+	Combinable    *bool      `json:"combinable"`
+	Active        *bool      `json:"active"`
+}
+
+type CreateCorporateAccountRequest struct {
+	Code            string     `json:"code" binding:"required,alphanum,max=20"`
+	Name            string     `json:"name" binding:"required"`
+	ContactName     string     `json:"contact_name"`
+	ContactEmail    string     `json:"contact_email" binding:"omitempty,email"`
+	RatePlan        string     `json:"rate_plan"` // defaults to CORP
+	DiscountPercent float64    `json:"discount_percent" binding:"min=0,max=100"`
+	ContractStart   *time.Time `json:"contract_start"`
+	ContractEnd     *time.Time `json:"contract_end"`
+}
+
+type UpdateCorporateAccountRequest struct {
+	Name            string     `json:"name"`
+	ContactName     *string    `json:"contact_name"`
+	ContactEmail    *string    `json:"contact_email" binding:"omitempty,email"`
+	RatePlan        *string    `json:"rate_plan"`
+	DiscountPercent *float64   `json:"discount_percent" binding:"omitempty,min=0,max=100"`
+	ContractStart   *time.Time `json:"contract_start"`
+	ContractEnd     *time.Time `json:"contract_end"`
+	Active          *bool      `json:"active"`
+}
+
+type LinkCorporateGuestRequest struct {
+	GuestID uint `json:"guest_id" binding:"required"`
+}
+
+type RedeemLoyaltyPointsRequest struct {
+	FolioChargeID uint  `json:"folio_charge_id" binding:"required"`
+	Points        int   `json:"points" binding:"required,min=1"`
+	StaffID       *uint `json:"staff_id"`
+}
+
+type AdjustLoyaltyPointsRequest struct {
+	Points  int    `json:"points" binding:"required"`
+	Reason  string `json:"reason" binding:"required"`
+	StaffID *uint  `json:"staff_id"`
+}
+
+type LoyaltyTierRequest struct {
+	Name         string  `json:"name" binding:"required,oneof=silver gold platinum"`
+	Rank         int     `json:"rank" binding:"required,min=1"`
+	MinStays     int     `json:"min_stays" binding:"min=0"`
+	MinSpend     float64 `json:"min_spend" binding:"min=0"`
+	BonusPercent float64 `json:"bonus_percent" binding:"min=0"`
+	Active       *bool   `json:"active"`
+}
+
+type CreateSalesChannelRequest struct {
+	Code        string   `json:"code" binding:"required,alphanum,max=20"`
+	Name        string   `json:"name" binding:"required"`
+	HotelCode   string   `json:"hotel_code"`
+	RatePlans   []string `json:"rate_plans"` // defaults to BAR
+	Currency    string   `json:"currency" binding:"omitempty,len=3"`
+	ARIFormat   string   `json:"ari_format" binding:"omitempty,oneof=json ota-xml"`
+	ARIEndpoint string   `json:"ari_endpoint" binding:"omitempty,url"`
+}
+
+type UpdateSalesChannelRequest struct {
+	Name        string   `json:"name"`
+	HotelCode   *string  `json:"hotel_code"`
+	RatePlans   []string `json:"rate_plans"`
+	Currency    string   `json:"currency" binding:"omitempty,len=3"`
+	ARIFormat   string   `json:"ari_format" binding:"omitempty,oneof=json ota-xml"`
+	ARIEndpoint *string  `json:"ari_endpoint" binding:"omitempty,url"`
+	Active      *bool    `json:"active"`
+}
+
+// InboundGuestRequest is required on the first message for a booking only
+type InboundGuestRequest struct {
+	Name        string `json:"name"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	Phone       string `json:"phone"`
+	Nationality string `json:"nationality"`
+}
+
+type InboundBookingRequest struct {
+	ConfirmationNumber string              `json:"confirmation_number" binding:"required"`
+	Status             string              `json:"status" binding:"omitempty,oneof=booked modified cancelled"`
+	Guest              InboundGuestRequest `json:"guest"`
+	RoomType           string              `json:"room_type" binding:"omitempty,oneof=Standard Deluxe Suite"`
+	RatePlan           string              `json:"rate_plan"`
+	CheckInDate        time.Time           `json:"check_in_date"`
+	CheckOutDate       time.Time           `json:"check_out_date"`
+	Guests             int                 `json:"guests" binding:"omitempty,min=1"`
+	TotalPrice         float64             `json:"total_price" binding:"min=0"`
+	PaidAmount         float64             `json:"paid_amount" binding:"min=0"`
+}
+
+type PublicSearchRequest struct {
+	CheckInDate   time.Time `json:"check_in_date" binding:"required"`
+	CheckOutDate  time.Time `json:"check_out_date" binding:"required,gtfield=CheckInDate"`
+	Guests        int       `json:"guests" binding:"required,min=1,max=10"`
+	PromotionCode string    `json:"promotion_code" binding:"max=32"`
+}
+
+type PublicQuoteRequest struct {
+	CheckInDate   time.Time `json:"check_in_date" binding:"required"`
+	CheckOutDate  time.Time `json:"check_out_date" binding:"required,gtfield=CheckInDate"`
+	Guests        int       `json:"guests" binding:"required,min=1,max=10"`
+	RoomType      string    `json:"room_type" binding:"required,oneof=Standard Deluxe Suite"`
+	RatePlan      string    `json:"rate_plan" binding:"omitempty,oneof=BAR NRF BB"`
+	PromotionCode string    `json:"promotion_code" binding:"max=32"`
+}
+
+type PublicBookingRequest struct {
+	PublicQuoteRequest
+	Name        string `json:"name" binding:"required,max=100"`
+	Email       string `json:"email" binding:"required,email"`
+	Phone       string `json:"phone" binding:"max=30"`
+	Nationality string `json:"nationality" binding:"max=60"`
+}
+
+type ConfirmPublicBookingRequest struct {
+	Token            string  `json:"token" binding:"required"`
+	DepositAmount    float64 `json:"deposit_amount" binding:"required,gt=0"`
+	PaymentReference string  `json:"payment_reference" binding:"required"` // checked with the payment provider before confirming
+}
+
+type GuestLoginLinkRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type GuestLoginRequest struct {
+	Token string `json:"token" binding:"required"` // from the magic link
+}
+
+type UpdateGuestPreferencesRequest struct {
+	RoomFloors      []string `json:"room_floors" binding:"max=10"`
+	MealTypes       []string `json:"meal_types" binding:"max=10"`
+	RoomTypes       []string `json:"room_types" binding:"max=10"`
+	SpecialRequests []string `json:"special_requests" binding:"max=10,dive,max=200"`
+}
+
+type PreRegisterCompanionRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	AgeCategory string `json:"age_category" binding:"omitempty,oneof=adult child"`
+	Nationality string `json:"nationality"` // adults: required with the ID
+	IDType      string `json:"id_type"`
+	IDNumber    string `json:"id_number"`
+}
+
+type PreRegisterRequest struct {
+	IDType      string                        `json:"id_type" binding:"required,max=40"` // Passport, Driver's License, National ID
+	IDNumber    string                        `json:"id_number" binding:"required,max=40"`
+	Nationality string                        `json:"nationality" binding:"required,max=60"`
+	Companions  []PreRegisterCompanionRequest `json:"companions" binding:"max=10,dive"`
+}
+
+type CreateWaitlistEntryRequest struct {
 	GuestID      uint      `json:"guest_id" binding:"required"`
-	RoomID       uint      `json:"room_id" binding:"required"`
 	CheckInDate  time.Time `json:"check_in_date" binding:"required"`
-	CheckOutDate time.Time `json:"check_out_date" binding:"required"`
-	TotalPrice   float64   `json:"total_price" binding:"required"`
+	CheckOutDate time.Time `json:"check_out_date" binding:"required,gtfield=CheckInDate"`
+	RoomTypes    []string  `json:"room_types" binding:"required,min=1,dive,oneof=Standard Deluxe Suite"`
+	Guests       int       `json:"guests" binding:"omitempty,min=1"`
+	Notes        string    `json:"notes"`
+}
+
+type CancelReservationRequest struct {
+	Reason string `json:"reason"`
+}
+
+type CreateCancellationPolicyRequest struct {
+	Name                  string  `json:"name" binding:"required"`
+	RatePlan              string  `json:"rate_plan"`
+	RoomType              string  `json:"room_type"`
+	NonRefundable         bool    `json:"non_refundable"`
+	FreeCancellationHours int     `json:"free_cancellation_hours" binding:"min=0"`
+	PenaltyType           string  `json:"penalty_type" binding:"required,oneof=none first-night percent full-stay"`
+	PenaltyPercent        float64 `json:"penalty_percent" binding:"min=0,max=100"`
+	NoShowPenaltyType     string  `json:"no_show_penalty_type" binding:"required,oneof=none first-night percent full-stay"`
+	NoShowPenaltyPercent  float64 `json:"no_show_penalty_percent" binding:"min=0,max=100"`
+}
+
+type UpdateCancellationPolicyRequest struct {
+	Name                  string   `json:"name"`
+	NonRefundable         *bool    `json:"non_refundable"`
+	FreeCancellationHours *int     `json:"free_cancellation_hours" binding:"omitempty,min=0"`
+	PenaltyType           string   `json:"penalty_type" binding:"omitempty,oneof=none first-night percent full-stay"`
+	PenaltyPercent        *float64 `json:"penalty_percent" binding:"omitempty,min=0,max=100"`
+	NoShowPenaltyType     string   `json:"no_show_penalty_type" binding:"omitempty,oneof=none first-night percent full-stay"`
+	NoShowPenaltyPercent  *float64 `json:"no_show_penalty_percent" binding:"omitempty,min=0,max=100"`
+	Active                *bool    `json:"active"`
+}
+
+type AddOccupantRequest struct {
+	Name        string `json:"name" binding:"required"`
+	AgeCategory string `json:"age_category" binding:"omitempty,oneof=adult child"`
+	GuestID     *uint  `json:"guest_id"`
+	Nationality string `json:"nationality"`
+	IDType      string `json:"id_type"`
+	IDNumber    string `json:"id_number"`
+}
+
+type UpdateOccupantRequest struct {
+	Name        string `json:"name"`
+	Nationality string `json:"nationality"`
+	IDType      string `json:"id_type"`
+	IDNumber    string `json:"id_number"`
+}
+
+type VerifyOccupantRequest struct {
+	StaffID uint `json:"staff_id" binding:"required"`
+}
+
+type CreateGroupBookingRequest struct {
+	Code           string                        `json:"code"`
+	Name           string                        `json:"name" binding:"required"`
+	GroupType      string                        `json:"group_type" binding:"required,oneof=wedding conference tour other"`
+	ContactGuestID *uint                         `json:"contact_guest_id"`
+	ContactName    string                        `json:"contact_name" binding:"required"`
+	ContactEmail   string                        `json:"contact_email" binding:"required,email"`
+	CheckInDate    time.Time                     `json:"check_in_date" binding:"required"`
+	CheckOutDate   time.Time                     `json:"check_out_date" binding:"required,gtfield=CheckInDate"`
+	ReleaseDate    time.Time                     `json:"release_date" binding:"required"`
+	MasterPaysRoom bool                          `json:"master_pays_room"`
+	Allotments     []CreateGroupAllotmentRequest `json:"allotments" binding:"required,min=1,dive"`
+	Notes          string                        `json:"notes"`
+}
+
+type CreateGroupAllotmentRequest struct {
+	RoomType     string  `json:"room_type" binding:"required"`
+	Rooms        int     `json:"rooms" binding:"required,min=1"`
+	RatePerNight float64 `json:"rate_per_night" binding:"required,gt=0"`
+}
+
+type ImportRoomingListRequest struct {
+	Entries []RoomingListEntryRequest `json:"entries" binding:"required,min=1,dive"`
+}
+
+type RoomingListEntryRequest struct {
+	Name         string     `json:"name" binding:"required"`
+	Email        string     `json:"email" binding:"required,email"`
+	Phone        string     `json:"phone"`
+	Nationality  string     `json:"nationality"`
+	RoomType     string     `json:"room_type" binding:"required"`
+	CheckInDate  *time.Time `json:"check_in_date"`
+	CheckOutDate *time.Time `json:"check_out_date"`
+}
+
+type PostGroupChargeRequest struct {
+	ChargeType  string  `json:"charge_type" binding:"required,oneof=event food-beverage adjustment"`
+	Description string  `json:"description" binding:"required"`
+	Amount      float64 `json:"amount" binding:"required"`
 }
 
 type CreateServiceRequestRequest struct {
@@ -372,6 +1777,43 @@ type CreateRoomServiceOrderItemRequest struct {
 	Quantity   int  `json:"quantity" binding:"required,min=1"`
 }
 
+type UpdateRoomServiceOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=accepted cooking ready out-for-delivery delivered cancelled"`
+}
+
+type CreateKitchenStationRequest struct {
+	Name               string   `json:"name" binding:"required"`
+	Categories         []string `json:"categories"`
+	DefaultPrepMinutes int      `json:"default_prep_minutes" binding:"required,min=1"`
+	Capacity           int      `json:"capacity" binding:"omitempty,min=1"`
+}
+
+type UpdateKitchenTicketStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=accepted cooking ready cancelled"`
+}
+
+type CreateIngredientRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	Unit              string  `json:"unit" binding:"required"`
+	StockQuantity     float64 `json:"stock_quantity" binding:"min=0"`
+	LowStockThreshold float64 `json:"low_stock_threshold" binding:"min=0"`
+}
+
+type AdjustIngredientStockRequest struct {
+	Change float64 `json:"change" binding:"required"`
+	Reason string  `json:"reason" binding:"required,oneof=restock adjustment waste"`
+	Notes  string  `json:"notes"`
+}
+
+type SetMenuItemRecipeRequest struct {
+	Items []SetMenuItemRecipeItemRequest `json:"items" binding:"required,dive"`
+}
+
+type SetMenuItemRecipeItemRequest struct {
+	IngredientID uint    `json:"ingredient_id" binding:"required"`
+	Quantity     float64 `json:"quantity" binding:"required,gt=0"`
+}
+
 type CreateHousekeepingRequestRequest struct {
 	ReservationID uint   `json:"reservation_id" binding:"required"`
 	GuestID       uint   `json:"guest_id" binding:"required"`
@@ -380,11 +1822,61 @@ type CreateHousekeepingRequestRequest struct {
 	ScheduleTime  string `json:"schedule_time"`
 }
 
+type GenerateHousekeepingScheduleRequest struct {
+	Date time.Time `json:"date" binding:"required"`
+}
+
+type AssignHousekeepingTaskRequest struct {
+	StaffID uint `json:"staff_id" binding:"required"`
+}
+
+type UpdateHousekeepingTaskStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=in-progress cleaned cancelled"`
+	Notes  string `json:"notes"`
+}
+
+type CreateInspectionChecklistRequest struct {
+	RoomType  string                                 `json:"room_type" binding:"required"`
+	Name      string                                 `json:"name" binding:"required"`
+	PassScore float64                                `json:"pass_score" binding:"omitempty,min=0,max=100"`
+	Items     []CreateInspectionChecklistItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+type CreateInspectionChecklistItemRequest struct {
+	Label     string `json:"label" binding:"required"`
+	Area      string `json:"area"`
+	Weight    int    `json:"weight" binding:"omitempty,min=1"`
+	Critical  bool   `json:"critical"`
+	SortOrder int    `json:"sort_order"`
+}
+
+type CreateRoomInspectionRequest struct {
+	InspectorID uint                                `json:"inspector_id" binding:"required"`
+	Results     []CreateRoomInspectionResultRequest `json:"results" binding:"required,min=1,dive"`
+	Photos      []CreateRoomInspectionPhotoRequest  `json:"photos" binding:"dive"`
+	Notes       string                              `json:"notes"`
+}
+
+type CreateRoomInspectionResultRequest struct {
+	ChecklistItemID uint   `json:"checklist_item_id" binding:"required"`
+	Passed          bool   `json:"passed"`
+	Notes           string `json:"notes"`
+}
+
+type CreateRoomInspectionPhotoRequest struct {
+	ChecklistItemID *uint  `json:"checklist_item_id"`
+	URL             string `json:"url" binding:"required,url"`
+	Caption         string `json:"caption"`
+}
+
 type CreateMaintenanceIssueRequest struct {
-	ReservationID uint   `json:"reservation_id" binding:"required"`
-	GuestID       uint   `json:"guest_id" binding:"required"`
-	IssueType     string `json:"issue_type" binding:"required"`
-	Description   string `json:"description" binding:"required"`
+	ReservationID  uint   `json:"reservation_id" binding:"required"`
+	GuestID        uint   `json:"guest_id" binding:"required"`
+	IssueType      string `json:"issue_type" binding:"required"`
+	Description    string `json:"description" binding:"required"`
+	Priority       string `json:"priority" binding:"omitempty,oneof=low medium high"`
+	AssetID        *uint  `json:"asset_id"`          // defaults to the room's asset matching the issue type
+	RoomOutOfOrder *bool  `json:"room_out_of_order"` // nil lets the issue type and priority decide
 }
 
 type CreateCheckInRequest struct {
@@ -398,13 +1890,83 @@ type CreateCheckInRequest struct {
 }
 
 type UpdateServiceRequestRequest struct {
-	Status     string `json:"status"`
-	AssignedTo string `json:"assigned_to"`
-	Notes      string `json:"notes"`
+	Status          string `json:"status"`
+	AssignedStaffID *uint  `json:"assigned_staff_id"` // must be active with a role that handles the request
+	Notes           string `json:"notes"`
+}
+
+type CreateShiftRequest struct {
+	StaffID      uint      `json:"staff_id" binding:"required"`
+	Role         string    `json:"role" binding:"omitempty,oneof=manager housekeeping maintenance front-desk room-service"`
+	StartsAt     time.Time `json:"starts_at" binding:"required"`
+	EndsAt       time.Time `json:"ends_at" binding:"required,gtfield=StartsAt"`
+	BreakMinutes int       `json:"break_minutes" binding:"omitempty,min=0"`
+	Notes        string    `json:"notes"`
+}
+
+type CreateRosterRequest struct {
+	Shifts []CreateShiftRequest `json:"shifts" binding:"required,min=1,dive"`
+}
+
+type UpdateShiftRequest struct {
+	StartsAt     *time.Time `json:"starts_at"`
+	EndsAt       *time.Time `json:"ends_at"`
+	BreakMinutes *int       `json:"break_minutes" binding:"omitempty,min=0"`
+	Status       string     `json:"status" binding:"omitempty,oneof=cancelled"`
+	Notes        string     `json:"notes"`
+}
+
+type AssignStaffRequest struct {
+	StaffID uint `json:"staff_id" binding:"required"`
+}
+
+type UpsertMaintenanceSLARequest struct {
+	IssueType          string `json:"issue_type"`
+	Priority           string `json:"priority" binding:"required,oneof=low medium high"`
+	AcknowledgeMinutes int    `json:"acknowledge_minutes" binding:"required,min=1"`
+	ResolveMinutes     int    `json:"resolve_minutes" binding:"required,min=1,gtefield=AcknowledgeMinutes"`
+}
+
+type CreateRoomAssetRequest struct {
+	AssetType    string     `json:"asset_type" binding:"required,oneof=ac tv water-heater door-lock"`
+	Name         string     `json:"name" binding:"required"`
+	Manufacturer string     `json:"manufacturer"`
+	ModelNumber  string     `json:"model_number"`
+	SerialNumber string     `json:"serial_number"`
+	InstalledAt  *time.Time `json:"installed_at"`
+}
+
+type UpdateRoomAssetRequest struct {
+	Name         string `json:"name"`
+	Manufacturer string `json:"manufacturer"`
+	ModelNumber  string `json:"model_number"`
+	SerialNumber string `json:"serial_number"`
+	Status       string `json:"status" binding:"omitempty,oneof=active retired"`
+}
+
+type CreatePreventiveMaintenanceScheduleRequest struct {
+	Task             string     `json:"task" binding:"required"`
+	IntervalDays     int        `json:"interval_days" binding:"required,min=1"`
+	ToleranceDays    int        `json:"tolerance_days" binding:"omitempty,min=0"`
+	EstimatedMinutes int        `json:"estimated_minutes" binding:"omitempty,min=1"`
+	FirstDueAt       *time.Time `json:"first_due_at"` // defaults to one interval from now
+}
+
+type GenerateWorkOrdersRequest struct {
+	HorizonDays int `json:"horizon_days" binding:"omitempty,min=1"` // default 14
+}
+
+type ScheduleWorkOrderRequest struct {
+	Night time.Time `json:"night" binding:"required"`
+}
+
+type UpdateWorkOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=in-progress completed cancelled"`
+	Notes  string `json:"notes"`
 }
 
 type UpdateMaintenanceIssueRequest struct {
-	Status     string `json:"status"`
-	AssignedTo string `json:"assigned_to"`
-	Notes      string `json:"notes"`
+	Status          string `json:"status"`
+	AssignedStaffID *uint  `json:"assigned_staff_id"` // must be active with a role that handles the request
+	Notes           string `json:"notes"`
 }